@@ -0,0 +1,296 @@
+// Package bulk processes exports of encoded passwords, e.g. before a migration.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuyang2/password-encoder/password"
+)
+
+type Format int
+
+const (
+	CSV   Format = iota // RFC 4180 with a header row
+	JSONL               // one JSON object per line
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "csv":
+		return CSV, nil
+	case "jsonl":
+		return JSONL, nil
+	default:
+		return 0, fmt.Errorf("bulk: unknown format %q", s)
+	}
+}
+
+const (
+	DefaultHashField        = "password"
+	DefaultMaxMalformedRows = 1000
+)
+
+type AuditOptions struct {
+	Format           Format
+	HashField        string // CSV column or JSON field of the encoded password, DefaultHashField if ""
+	KeyField         string // CSV column or JSON field identifying rows in MalformedRows, e.g. "id", optional
+	MaxMalformedRows int    // DefaultMaxMalformedRows if 0, the Malformed count is exact
+}
+
+// Class groups encoded passwords with the same encoder and parameters.
+type Class struct {
+	Id          string `json:"id,omitempty"`
+	Algorithm   string `json:"algorithm,omitempty"` // "" if ambiguous
+	Cost        int    `json:"cost,omitempty"`
+	BlockSize   int    `json:"blockSize,omitempty"`
+	Memory      int    `json:"memory,omitempty"`
+	Iterations  int    `json:"iterations,omitempty"`
+	Parallelism int    `json:"parallelism,omitempty"`
+	UnknownId   bool   `json:"unknownId,omitempty"` // no PasswordEncoder for Id
+	Upgrade     bool   `json:"upgrade"`
+}
+
+type ClassCount struct {
+	Class
+	Count int64 `json:"count"`
+}
+
+// MalformedRow is a row without a parsable encoded password, the encoded password itself is not recorded.
+type MalformedRow struct {
+	Line int64  `json:"line"` // 1-based line of the row, for CSV the line where the record starts
+	Key  string `json:"key,omitempty"`
+	Err  string `json:"err"`
+}
+
+type Report struct {
+	Rows       int64 `json:"rows"`
+	Upgrade    int64 `json:"upgrade"`    // rows for which UpgradeEncoding is true, including unprefixed rows
+	Unprefixed int64 `json:"unprefixed"` // rows without "{id}" prefix, classified by password.Inspect
+	UnknownId  int64 `json:"unknownId"`  // rows with an "{id}" without PasswordEncoder, counted as Upgrade
+	Malformed  int64 `json:"malformed"`
+
+	Classes       []ClassCount   `json:"classes"` // by descending Count
+	MalformedRows []MalformedRow `json:"malformedRows"`
+
+	classes map[Class]int64
+}
+
+// Audit streams an export of encoded passwords and classifies each with encoder.Inspect,
+// i.e. with the logic of DelegatingPasswordEncoder.UpgradeEncoding.
+//
+// Rows without an "{id}" prefix cannot be matched by a DelegatingPasswordEncoder,
+// they are counted as Unprefixed and Upgrade. Rows with an unknown "{id}", e.g. "{MD5}", are counted
+// as UnknownId and Upgrade in a class of their id. Rows that cannot be parsed are counted as Malformed.
+// The returned error is an I/O or header error that stops the audit.
+func Audit(r io.Reader, encoder *password.DelegatingPasswordEncoder, opts AuditOptions) (*Report, error) {
+	if opts.HashField == "" {
+		opts.HashField = DefaultHashField
+	}
+	if opts.MaxMalformedRows == 0 {
+		opts.MaxMalformedRows = DefaultMaxMalformedRows
+	}
+
+	report := &Report{classes: make(map[Class]int64)}
+	var err error
+	switch opts.Format {
+	case CSV:
+		err = readCSV(r, opts, report, encoder)
+	case JSONL:
+		err = readJSONL(r, opts, report, encoder)
+	default:
+		err = fmt.Errorf("bulk: unknown format %d", opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for class, count := range report.classes {
+		report.Classes = append(report.Classes, ClassCount{Class: class, Count: count})
+	}
+	sort.Slice(report.Classes, func(i, j int) bool {
+		if report.Classes[i].Count != report.Classes[j].Count {
+			return report.Classes[i].Count > report.Classes[j].Count
+		}
+		return fmt.Sprint(report.Classes[i].Class) < fmt.Sprint(report.Classes[j].Class)
+	})
+	return report, nil
+}
+
+func (report *Report) add(line int64, key string, encodedPassword string, encoder *password.DelegatingPasswordEncoder, opts AuditOptions) {
+	report.Rows++
+
+	var info password.HashInfo
+	var err error
+	if !strings.HasPrefix(encodedPassword, password.DefaultIdPrefix) {
+		info, err = password.Inspect(encodedPassword)
+		if err == nil {
+			report.Unprefixed++
+			info.UpgradeEncoding = true
+		}
+	} else {
+		info, err = encoder.Inspect(encodedPassword)
+		var unknownId *password.UnknownIdError
+		if errors.As(err, &unknownId) {
+			report.UnknownId++
+			report.Upgrade++
+			report.classes[Class{Id: unknownId.Id, UnknownId: true, Upgrade: true}]++
+			return
+		}
+	}
+	if err != nil {
+		report.malformed(line, key, err, opts)
+		return
+	}
+
+	if info.UpgradeEncoding {
+		report.Upgrade++
+	}
+	report.classes[Class{
+		Id:          info.Id,
+		Algorithm:   info.Algorithm,
+		Cost:        info.Cost,
+		BlockSize:   info.BlockSize,
+		Memory:      info.Memory,
+		Iterations:  info.Iterations,
+		Parallelism: info.Parallelism,
+		Upgrade:     info.UpgradeEncoding,
+	}]++
+}
+
+func (report *Report) malformed(line int64, key string, err error, opts AuditOptions) {
+	report.Malformed++
+	if len(report.MalformedRows) < opts.MaxMalformedRows {
+		report.MalformedRows = append(report.MalformedRows, MalformedRow{Line: line, Key: key, Err: err.Error()})
+	}
+}
+
+func readCSV(r io.Reader, opts AuditOptions, report *Report, encoder *password.DelegatingPasswordEncoder) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("bulk: reading CSV header: %w", err)
+	}
+	hashColumn, keyColumn, err := columns(header, opts.HashField, opts.KeyField)
+	if err != nil {
+		return err
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Rows++
+			report.malformed(int64(parseErr.StartLine), "", err, opts)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+
+		key := field(record, keyColumn)
+		if hashColumn >= len(record) {
+			report.Rows++
+			report.malformed(int64(line), key, errors.New("missing column "+strconv.Quote(opts.HashField)), opts)
+			continue
+		}
+		report.add(int64(line), key, record[hashColumn], encoder, opts)
+	}
+}
+
+func readJSONL(r io.Reader, opts AuditOptions, report *Report, encoder *password.DelegatingPasswordEncoder) error {
+	br := bufio.NewReader(r)
+	for line := int64(1); ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			report.addJSON(line, b, encoder, opts)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+func (report *Report) addJSON(line int64, b []byte, encoder *password.DelegatingPasswordEncoder, opts AuditOptions) {
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(b, &row); err != nil {
+		report.Rows++
+		report.malformed(line, "", err, opts)
+		return
+	}
+
+	key := jsonKey(row, opts.KeyField)
+	encodedPassword, err := jsonString(row, opts.HashField)
+	if err != nil {
+		report.Rows++
+		report.malformed(line, key, err, opts)
+		return
+	}
+	report.add(line, key, encodedPassword, encoder, opts)
+}
+
+// columns returns the indexes of the hash and key columns in a CSV header, keyColumn is -1 without keyField
+func columns(header []string, hashField, keyField string) (hashColumn, keyColumn int, err error) {
+	hashColumn, keyColumn = -1, -1
+	for i, name := range header {
+		switch {
+		case name == hashField:
+			hashColumn = i
+		case name == keyField && keyField != "":
+			keyColumn = i
+		}
+	}
+	if hashColumn < 0 {
+		return -1, -1, fmt.Errorf("bulk: CSV header has no column %q", hashField)
+	}
+	return hashColumn, keyColumn, nil
+}
+
+func field(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return record[column]
+}
+
+func jsonKey(row map[string]json.RawMessage, keyField string) string {
+	raw, ok := row[keyField]
+	if !ok || keyField == "" {
+		return ""
+	}
+	var key string
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return string(raw) // e.g. a numeric id
+	}
+	return key
+}
+
+func jsonString(row map[string]json.RawMessage, name string) (string, error) {
+	raw, ok := row[name]
+	if !ok {
+		return "", errors.New("missing field " + strconv.Quote(name))
+	}
+	var s string
+	if !bytes.HasPrefix(raw, []byte(`"`)) || json.Unmarshal(raw, &s) != nil { // null decodes to ""
+		return "", fmt.Errorf("field %q is not a string", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,136 @@
+package bulk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/password"
+)
+
+const (
+	bcrypt10 = "$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG"
+	scrypt   = "$e0801$8bWJaSu2IKSn9Z9kM+TPXfOc/9bdYSrN1oD9qfVThWEwdRTnO7re7Ei+fUZRJ68k9lTyuTeUp4of4g24hHnazw==$OAOec05+bXxvuu/1qZ6NUR+xQYvYv7BeL1QxwRpY5Pc="
+)
+
+func newEncoder() *password.DelegatingPasswordEncoder {
+	return password.NewDelegatingPasswordEncoder("bcrypt", map[string]password.PasswordEncoder{
+		"bcrypt": password.NewBCryptPasswordEncoder(bcrypt.DefaultCost),
+		"scrypt": password.DefaultSCryptPasswordEncoder(),
+		"sha256": password.NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(8)),
+	})
+}
+
+func TestAudit_CSV(t *testing.T) {
+	export := `id,email,password
+1,a@example.com,{bcrypt}` + bcrypt10 + `
+2,b@example.com,{bcrypt}` + bcrypt10 + `
+3,c@example.com,"{scrypt}` + scrypt + `"
+4,d@example.com,` + bcrypt10 + `
+5,e@example.com,{bcrypt}broken
+6,f@example.com,{md5}5f4dcc3b5aa765d61d8327deb882cf99
+7,g@example.com
+8,"h@example.com,{bcrypt}` + bcrypt10 + `
+`
+	report, err := Audit(strings.NewReader(export), newEncoder(), AuditOptions{Format: CSV, KeyField: "id"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(8), report.Rows)
+	assert.Equal(t, int64(3), report.Upgrade)
+	assert.Equal(t, int64(1), report.Unprefixed)
+	assert.Equal(t, int64(1), report.UnknownId)
+	assert.Equal(t, int64(3), report.Malformed)
+
+	assert.Equal(t, []ClassCount{
+		{Class: Class{Id: "bcrypt", Algorithm: "bcrypt", Cost: 10}, Count: 2},
+		{Class: Class{Algorithm: "bcrypt", Cost: 10, Upgrade: true}, Count: 1},
+		{Class: Class{Id: "md5", UnknownId: true, Upgrade: true}, Count: 1},
+		{Class: Class{Id: "scrypt", Algorithm: "scrypt", Cost: 16384, BlockSize: 8, Parallelism: 1, Upgrade: true}, Count: 1},
+	}, report.Classes)
+
+	require.Len(t, report.MalformedRows, 3)
+	assert.Equal(t, MalformedRow{Line: 6, Key: "5", Err: password.ErrUnrecognizedEncoding.Error()}, report.MalformedRows[0])
+	assert.Equal(t, int64(8), report.MalformedRows[1].Line)
+	assert.Equal(t, "7", report.MalformedRows[1].Key)
+	assert.Equal(t, int64(9), report.MalformedRows[2].Line) // unterminated quote
+	assert.False(t, strings.Contains(report.MalformedRows[2].Err, bcrypt10))
+}
+
+func TestAudit_cost(t *testing.T) {
+	export := `{"password": "{bcrypt}` + bcrypt10 + `"}
+{"password": "{scrypt}` + scrypt + `"}`
+
+	encoder := password.NewDelegatingPasswordEncoder("bcrypt", map[string]password.PasswordEncoder{
+		"bcrypt": password.NewBCryptPasswordEncoder(12),
+	})
+	report, err := Audit(strings.NewReader(export[:strings.Index(export, "\n")]), encoder, AuditOptions{Format: JSONL})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Upgrade)
+	assert.Equal(t, []ClassCount{{Class: Class{Id: "bcrypt", Algorithm: "bcrypt", Cost: 10, Upgrade: true}, Count: 1}}, report.Classes)
+
+	encoder = password.NewDelegatingPasswordEncoder("scrypt", map[string]password.PasswordEncoder{
+		"bcrypt": password.NewBCryptPasswordEncoder(bcrypt.DefaultCost),
+		"scrypt": password.DefaultSCryptPasswordEncoder(),
+	})
+	report, err = Audit(strings.NewReader(export), encoder, AuditOptions{Format: JSONL})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Upgrade)
+	assert.Equal(t, []ClassCount{
+		{Class: Class{Id: "bcrypt", Algorithm: "bcrypt", Cost: 10, Upgrade: true}, Count: 1},
+		{Class: Class{Id: "scrypt", Algorithm: "scrypt", Cost: 16384, BlockSize: 8, Parallelism: 1, Upgrade: true}, Count: 1},
+	}, report.Classes)
+}
+
+func TestAudit_JSONL(t *testing.T) {
+	export := `{"id": 1, "password": "{bcrypt}` + bcrypt10 + `"}
+{"id": "2", "password": "{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0"}
+
+{"id": 3, "password": null}
+{"id": 4}
+not json
+{"id": 5, "password": "{bcrypt}` + bcrypt10 + `"}`
+
+	report, err := Audit(strings.NewReader(export), newEncoder(), AuditOptions{Format: JSONL, KeyField: "id", MaxMalformedRows: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(6), report.Rows)
+	assert.Equal(t, int64(1), report.Upgrade)
+	assert.Equal(t, int64(3), report.Malformed)
+	assert.Equal(t, []ClassCount{
+		{Class: Class{Id: "bcrypt", Algorithm: "bcrypt", Cost: 10}, Count: 2},
+		{Class: Class{Id: "sha256", Algorithm: "sha256", Upgrade: true}, Count: 1},
+	}, report.Classes)
+
+	assert.Equal(t, []MalformedRow{
+		{Line: 4, Key: "3", Err: `field "password" is not a string`},
+		{Line: 5, Key: "4", Err: `missing field "password"`},
+	}, report.MalformedRows)
+}
+
+func TestAudit_errors(t *testing.T) {
+	_, err := Audit(strings.NewReader("id,hash\n1,x\n"), newEncoder(), AuditOptions{Format: CSV})
+	assert.Error(t, err)
+
+	_, err = Audit(strings.NewReader(""), newEncoder(), AuditOptions{Format: CSV})
+	assert.Error(t, err)
+
+	_, err = Audit(strings.NewReader(""), newEncoder(), AuditOptions{Format: Format(9)})
+	assert.Error(t, err)
+
+	report, err := Audit(strings.NewReader("id,hash\n1,{bcrypt}"+bcrypt10+"\n"), newEncoder(), AuditOptions{Format: CSV, HashField: "hash"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Rows)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("jsonl")
+	assert.NoError(t, err)
+	assert.Equal(t, JSONL, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
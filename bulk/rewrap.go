@@ -0,0 +1,304 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/xuyang2/password-encoder/password"
+)
+
+type RewrapOptions struct {
+	Format           Format
+	HashField        string // CSV column or JSON field of the encoded password, DefaultHashField if ""
+	KeyField         string // CSV column or JSON field identifying rows in MalformedRows, e.g. "id", optional
+	MaxMalformedRows int    // DefaultMaxMalformedRows if 0, the Malformed count is exact
+
+	FromId     string // id of the legacy encoded passwords, e.g. "sha256"
+	ToId       string // id of the OnionPasswordEncoder, e.g. "sha256-bcrypt"
+	Unprefixed bool   // also wrap encoded passwords without "{id}" prefix
+	Workers    int    // concurrent Wrap calls, runtime.GOMAXPROCS(0) if 0
+}
+
+type RewrapReport struct {
+	Rows      int64 `json:"rows"`
+	Rewrapped int64 `json:"rewrapped"`
+	Skipped   int64 `json:"skipped"`   // rows with another id, written unchanged
+	Malformed int64 `json:"malformed"` // rows that could not be wrapped, written unchanged
+
+	MalformedRows []MalformedRow `json:"malformedRows"`
+}
+
+// Rewrap streams an export of encoded passwords from r to w, replacing each "{FromId}" encoded password
+// with "{ToId}" and the result of onion.Wrap. Other rows are written unchanged and in order.
+//
+// CSV rows keep their fields; JSONL rows that are rewrapped are re-marshalled with sorted keys.
+// CSV syntax errors stop the rewrap as the row cannot be written back.
+func Rewrap(r io.Reader, w io.Writer, onion *password.OnionPasswordEncoder, opts RewrapOptions) (*RewrapReport, error) {
+	if opts.FromId == "" || opts.ToId == "" {
+		return nil, errors.New("bulk: FromId and ToId are required")
+	}
+	if opts.HashField == "" {
+		opts.HashField = DefaultHashField
+	}
+	if opts.MaxMalformedRows == 0 {
+		opts.MaxMalformedRows = DefaultMaxMalformedRows
+	}
+	if opts.Workers == 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	bw := bufio.NewWriter(w)
+	rw := &rewrapper{
+		opts:   opts,
+		report: &RewrapReport{},
+		jobs:   make(chan *rewrapJob),
+		queue:  make(chan *rewrapJob, 2*opts.Workers),
+	}
+
+	g, ctx := errgroup.WithContext(context.Background())
+	for i := 0; i < opts.Workers; i++ {
+		g.Go(func() error {
+			for job := range rw.jobs {
+				job.wrapped, job.err = onion.WrapContext(ctx, job.encodedPassword)
+				close(job.done)
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		return rw.write(ctx)
+	})
+	g.Go(func() error {
+		defer close(rw.jobs)
+		defer close(rw.queue)
+		switch opts.Format {
+		case CSV:
+			return rw.readCSV(ctx, r, bw)
+		case JSONL:
+			return rw.readJSONL(ctx, r, bw)
+		default:
+			return fmt.Errorf("bulk: unknown format %d", opts.Format)
+		}
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return rw.report, nil
+}
+
+type rewrapper struct {
+	opts   RewrapOptions
+	report *RewrapReport // only updated by write
+
+	jobs  chan *rewrapJob // to the workers
+	queue chan *rewrapJob // to write, in input order
+}
+
+// rewrapJob is a row, wrapped by a worker if wrap is true
+type rewrapJob struct {
+	line  int64
+	key   string
+	wrap  bool
+	noRow bool // e.g. a blank line, only emitted
+
+	encodedPassword string
+	wrapped         string
+	err             error // a row error, or the error of Wrap
+	done            chan struct{}
+
+	// emit writes the row with encodedPassword, or the row unchanged if encodedPassword is ""
+	emit func(encodedPassword string) error
+}
+
+// submit queues a row for writing, and for wrapping if the encoded password has FromId
+func (rw *rewrapper) submit(ctx context.Context, job *rewrapJob) error {
+	job.done = make(chan struct{})
+	if job.err == nil && !job.noRow {
+		job.encodedPassword, job.wrap = rw.legacy(job.encodedPassword)
+	}
+	if !job.wrap {
+		close(job.done)
+	}
+
+	select {
+	case rw.queue <- job:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if job.wrap {
+		select {
+		case rw.jobs <- job:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// legacy returns the encoded password without "{FromId}" prefix if it should be wrapped
+func (rw *rewrapper) legacy(prefixEncodedPassword string) (string, bool) {
+	prefix := password.DefaultIdPrefix + rw.opts.FromId + password.DefaultIdSuffix
+	if strings.HasPrefix(prefixEncodedPassword, prefix) {
+		return strings.TrimPrefix(prefixEncodedPassword, prefix), true
+	}
+	if rw.opts.Unprefixed && !strings.HasPrefix(prefixEncodedPassword, password.DefaultIdPrefix) {
+		return prefixEncodedPassword, true
+	}
+	return prefixEncodedPassword, false
+}
+
+func (rw *rewrapper) write(ctx context.Context) error {
+	for job := range rw.queue {
+		select {
+		case <-job.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		encodedPassword := ""
+		switch {
+		case job.noRow:
+		case job.err != nil:
+			rw.report.Rows++
+			rw.report.Malformed++
+			if len(rw.report.MalformedRows) < rw.opts.MaxMalformedRows {
+				rw.report.MalformedRows = append(rw.report.MalformedRows, MalformedRow{Line: job.line, Key: job.key, Err: job.err.Error()})
+			}
+		case job.wrap:
+			rw.report.Rows++
+			rw.report.Rewrapped++
+			encodedPassword = password.DefaultIdPrefix + rw.opts.ToId + password.DefaultIdSuffix + job.wrapped
+		default:
+			rw.report.Rows++
+			rw.report.Skipped++
+		}
+		if err := job.emit(encodedPassword); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rw *rewrapper) readCSV(ctx context.Context, r io.Reader, w *bufio.Writer) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cw := csv.NewWriter(w)
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("bulk: reading CSV header: %w", err)
+	}
+	hashColumn, keyColumn, err := columns(header, rw.opts.HashField, rw.opts.KeyField)
+	if err != nil {
+		return err
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("bulk: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		job := &rewrapJob{
+			line: int64(line),
+			key:  field(record, keyColumn),
+			emit: func(encodedPassword string) error {
+				if encodedPassword != "" {
+					record[hashColumn] = encodedPassword
+				}
+				return cw.Write(record)
+			},
+		}
+		if hashColumn < len(record) {
+			job.encodedPassword = record[hashColumn]
+		} else {
+			job.err = fmt.Errorf("missing column %q", rw.opts.HashField)
+		}
+		if err := rw.submit(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	// flushed after the last row
+	return rw.submit(ctx, &rewrapJob{
+		noRow: true,
+		emit: func(string) error {
+			cw.Flush()
+			return cw.Error()
+		},
+	})
+}
+
+func (rw *rewrapper) readJSONL(ctx context.Context, r io.Reader, w *bufio.Writer) error {
+	br := bufio.NewReader(r)
+	for line := int64(1); ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if len(b) > 0 {
+			if err := rw.submit(ctx, rw.jsonJob(line, b, w)); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+func (rw *rewrapper) jsonJob(line int64, b []byte, w *bufio.Writer) *rewrapJob {
+	job := &rewrapJob{line: line}
+
+	var row map[string]json.RawMessage
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 {
+		job.noRow = true
+	} else if err := json.Unmarshal(trimmed, &row); err != nil {
+		job.err = err
+	} else {
+		job.key = jsonKey(row, rw.opts.KeyField)
+		job.encodedPassword, job.err = jsonString(row, rw.opts.HashField)
+	}
+
+	job.emit = func(encodedPassword string) error {
+		if encodedPassword == "" {
+			_, err := w.Write(b)
+			if err == nil && !bytes.HasSuffix(b, []byte("\n")) {
+				err = w.WriteByte('\n')
+			}
+			return err
+		}
+
+		raw, err := json.Marshal(encodedPassword)
+		if err != nil {
+			return err
+		}
+		row[rw.opts.HashField] = raw
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(row)
+	}
+	return job
+}
@@ -0,0 +1,128 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/password"
+)
+
+func newOnion() (*password.Sha256PasswordEncoder, *password.OnionPasswordEncoder, *password.DelegatingPasswordEncoder) {
+	legacy := password.NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(8))
+	onion := password.NewOnionPasswordEncoder(legacy, password.NewBCryptPasswordEncoder(bcrypt.MinCost))
+	encoder := password.NewDelegatingPasswordEncoder("bcrypt", map[string]password.PasswordEncoder{
+		"bcrypt":        password.NewBCryptPasswordEncoder(bcrypt.MinCost),
+		"sha256":        legacy,
+		"sha256-bcrypt": onion,
+	})
+	return legacy, onion, encoder
+}
+
+func TestRewrap_CSV(t *testing.T) {
+	legacy, onion, encoder := newOnion()
+
+	var export strings.Builder
+	export.WriteString("id,email,password\n")
+	rawPasswords := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		id, rawPassword := string(rune('a'+i)), "password"+string(rune('a'+i))
+		encodedPassword, err := legacy.Encode(rawPassword)
+		require.NoError(t, err)
+		if i%2 == 0 {
+			encodedPassword = "{sha256}" + encodedPassword
+		}
+		rawPasswords[id] = rawPassword
+		export.WriteString(id + "," + id + "@example.com," + encodedPassword + "\n")
+	}
+	export.WriteString("u,\"u@example.com, quoted\"," + bcrypt10 + "\n")
+	export.WriteString("v,v@example.com,{sha256}zz\n")
+	export.WriteString("w,w@example.com\n")
+
+	var out bytes.Buffer
+	report, err := Rewrap(strings.NewReader(export.String()), &out, onion, RewrapOptions{
+		Format: CSV, KeyField: "id", FromId: "sha256", ToId: "sha256-bcrypt", Unprefixed: true, Workers: 4,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(23), report.Rows)
+	assert.Equal(t, int64(20), report.Rewrapped)
+	assert.Equal(t, int64(0), report.Skipped)
+	assert.Equal(t, int64(3), report.Malformed)
+	require.Len(t, report.MalformedRows, 3)
+	assert.Equal(t, []string{"u", "v", "w"}, []string{report.MalformedRows[0].Key, report.MalformedRows[1].Key, report.MalformedRows[2].Key})
+
+	cr := csv.NewReader(&out)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 24)
+	assert.Equal(t, []string{"id", "email", "password"}, records[0])
+	for i, record := range records[1:21] {
+		assert.Equal(t, string(rune('a'+i)), record[0], "order is kept")
+		assert.True(t, strings.HasPrefix(record[2], "{sha256-bcrypt}"))
+		assert.True(t, encoder.Matches(rawPasswords[record[0]], record[2]))
+		assert.True(t, encoder.UpgradeEncoding(record[2]))
+	}
+	assert.Equal(t, []string{"u", "u@example.com, quoted", bcrypt10}, records[21])
+	assert.Equal(t, []string{"v", "v@example.com", "{sha256}zz"}, records[22])
+	assert.Equal(t, []string{"w", "w@example.com"}, records[23])
+}
+
+func TestRewrap_JSONL(t *testing.T) {
+	legacy, onion, encoder := newOnion()
+	encodedPassword, err := legacy.Encode("password")
+	require.NoError(t, err)
+
+	export := `{"id": 1, "password": "{sha256}` + encodedPassword + `", "note": "<b>"}` + "\n" +
+		"\n" +
+		`{"id": 2, "password": "{bcrypt}` + bcrypt10 + `"}` + "\n" +
+		`{"id": 3, "password": "` + encodedPassword + `"}` + "\n" +
+		`not json`
+
+	var out bytes.Buffer
+	report, err := Rewrap(strings.NewReader(export), &out, onion, RewrapOptions{Format: JSONL, FromId: "sha256", ToId: "sha256-bcrypt"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), report.Rows)
+	assert.Equal(t, int64(1), report.Rewrapped)
+	assert.Equal(t, int64(2), report.Skipped)
+	assert.Equal(t, int64(1), report.Malformed)
+
+	lines := strings.Split(out.String(), "\n")
+	require.Len(t, lines, 6)
+	var row struct {
+		Id       int
+		Password string
+		Note     string
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.Equal(t, 1, row.Id)
+	assert.Equal(t, "<b>", row.Note)
+	assert.True(t, encoder.Matches("password", row.Password))
+	assert.Contains(t, lines[0], `"<b>"`)
+
+	assert.Equal(t, []string{"", `{"id": 2, "password": "{bcrypt}` + bcrypt10 + `"}`, `{"id": 3, "password": "` + encodedPassword + `"}`, "not json", ""}, lines[1:])
+}
+
+func TestRewrap_errors(t *testing.T) {
+	_, onion, _ := newOnion()
+
+	_, err := Rewrap(strings.NewReader("id,password\n"), &bytes.Buffer{}, onion, RewrapOptions{Format: CSV, FromId: "sha256"})
+	assert.Error(t, err)
+
+	_, err = Rewrap(strings.NewReader("id,hash\n"), &bytes.Buffer{}, onion, RewrapOptions{Format: CSV, FromId: "sha256", ToId: "sha256-bcrypt"})
+	assert.Error(t, err)
+
+	_, err = Rewrap(strings.NewReader("id,password\n1,\"x\n"), &bytes.Buffer{}, onion, RewrapOptions{Format: CSV, FromId: "sha256", ToId: "sha256-bcrypt"})
+	assert.Error(t, err)
+
+	_, err = Rewrap(strings.NewReader(""), &bytes.Buffer{}, onion, RewrapOptions{Format: Format(9), FromId: "sha256", ToId: "sha256-bcrypt"})
+	assert.Error(t, err)
+}
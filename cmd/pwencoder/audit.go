@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/xuyang2/password-encoder/bulk"
+	"github.com/xuyang2/password-encoder/password"
+)
+
+func audit(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var f encoderFlags
+	fs := newFlagSet("audit", stderr)
+	f.register(fs)
+	format := fs.String("format", "csv", "export format: csv (with header) or jsonl")
+	field := fs.String("field", bulk.DefaultHashField, "column or field of the encoded password")
+	key := fs.String("key", "", "column or field identifying malformed rows, e.g. id")
+	maxMalformed := fs.Int("max-malformed", bulk.DefaultMaxMalformedRows, "malformed rows to list")
+	minStrength := fs.String("min-strength", "unknown", "upgrade encoders classified below: insecure, legacy or strong")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if status := parse(fs, args, 1); status >= 0 {
+		return status
+	}
+
+	opts := bulk.AuditOptions{HashField: *field, KeyField: *key, MaxMalformedRows: *maxMalformed}
+	var err error
+	if opts.Format, err = bulk.ParseFormat(*format); err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+	strength, err := parseStrength(*minStrength)
+	if err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+	encoder, err := f.delegating(password.WithMinStrength(strength))
+	if err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+
+	r := stdin
+	if name := fs.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return fail(stderr, exitError, err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	report, err := bulk.Audit(r, encoder, opts)
+	if err != nil {
+		return fail(stderr, exitError, err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fail(stderr, exitError, err)
+		}
+	} else {
+		printReport(stdout, report)
+	}
+
+	switch {
+	case report.Malformed > 0:
+		return exitMalformed
+	case report.Upgrade > 0:
+		return exitMismatch
+	default:
+		return exitOK
+	}
+}
+
+func printReport(w io.Writer, report *bulk.Report) {
+	fmt.Fprintf(w, "%-16s %d\n", "rows:", report.Rows)
+	fmt.Fprintf(w, "%-16s %d\n", "upgrade:", report.Upgrade)
+	fmt.Fprintf(w, "%-16s %d\n", "unprefixed:", report.Unprefixed)
+	fmt.Fprintf(w, "%-16s %d\n", "unknown id:", report.UnknownId)
+	fmt.Fprintf(w, "%-16s %d\n", "malformed:", report.Malformed)
+
+	if len(report.Classes) > 0 {
+		fmt.Fprintln(w, "\nclasses:")
+	}
+	for _, class := range report.Classes {
+		var params []string
+		param := func(name string, value int) {
+			if value != 0 {
+				params = append(params, fmt.Sprintf("%s=%d", name, value))
+			}
+		}
+		param("cost", class.Cost)
+		param("r", class.BlockSize)
+		param("m", class.Memory)
+		param("t", class.Iterations)
+		param("p", class.Parallelism)
+
+		id := class.Id
+		if id == "" {
+			id = "-"
+		}
+		algorithm := class.Algorithm
+		switch {
+		case class.UnknownId:
+			algorithm = "unknown id"
+		case algorithm == "":
+			algorithm = "?"
+		}
+		fmt.Fprintf(w, "%10d  %-12s %-12s %-28s upgrade=%t\n", class.Count, id, algorithm, strings.Join(params, ","), class.Upgrade)
+	}
+
+	printMalformedRows(w, report.MalformedRows, report.Malformed)
+}
+
+func printMalformedRows(w io.Writer, rows []bulk.MalformedRow, malformed int64) {
+	if len(rows) > 0 {
+		fmt.Fprintln(w, "\nmalformed rows:")
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "  line %d", row.Line)
+		if row.Key != "" {
+			fmt.Fprintf(w, " (%s)", row.Key)
+		}
+		fmt.Fprintf(w, ": %s\n", row.Err)
+	}
+	if n := malformed - int64(len(rows)); n > 0 {
+		fmt.Fprintf(w, "  ... %d more\n", n)
+	}
+}
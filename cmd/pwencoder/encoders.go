@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"flag"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/emmansun/gmsm/sm3"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/password"
+)
+
+// encoderFlags are the parameters of the encoders of a DelegatingPasswordEncoder,
+// registered on the flag.FlagSet of each subcommand.
+type encoderFlags struct {
+	id         string
+	saltLength int
+
+	bcryptCost    int
+	bcryptVersion string
+	bcryptPreHash bool
+
+	pbkdf2Iterations int
+	pbkdf2Hash       string
+
+	pbkdf2Sm3Iterations int
+
+	scryptN int
+	scryptR int
+	scryptP int
+
+	argon2Memory      int
+	argon2Iterations  int
+	argon2Parallelism int
+}
+
+var pbkdf2Hashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"sm3":    sm3.New,
+}
+
+// the defaults are those of the Default* constructors
+func (f *encoderFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.id, "id", "bcrypt", "encoder id: "+strings.Join(encoderIds(), ", "))
+	fs.IntVar(&f.saltLength, "salt-length", 16, "salt length in bytes")
+
+	fs.IntVar(&f.bcryptCost, "bcrypt-cost", 10, "bcrypt cost")
+	fs.StringVar(&f.bcryptVersion, "bcrypt-version", "2a", "bcrypt version: 2a, 2b or 2y")
+	fs.BoolVar(&f.bcryptPreHash, "bcrypt-prehash", false, "bcrypt HMAC-SHA-384 pre-hash for passwords over 72 bytes, instead of rejecting them")
+
+	fs.IntVar(&f.pbkdf2Iterations, "pbkdf2-iterations", 310000, "pbkdf2 iterations, not recorded in the encoded password")
+	fs.StringVar(&f.pbkdf2Hash, "pbkdf2-hash", "sha256", "pbkdf2 hash: sha1, sha256, sha512 or sm3")
+
+	fs.IntVar(&f.pbkdf2Sm3Iterations, "pbkdf2-sm3-iterations", 600000, "pbkdf2-sm3 iterations")
+
+	fs.IntVar(&f.scryptN, "scrypt-n", 65536, "scrypt cpu cost N")
+	fs.IntVar(&f.scryptR, "scrypt-r", 8, "scrypt memory cost r")
+	fs.IntVar(&f.scryptP, "scrypt-p", 1, "scrypt parallelization p")
+
+	fs.IntVar(&f.argon2Memory, "argon2-memory", 1<<14, "argon2 memory in KiB")
+	fs.IntVar(&f.argon2Iterations, "argon2-iterations", 2, "argon2 iterations")
+	fs.IntVar(&f.argon2Parallelism, "argon2-parallelism", 1, "argon2 parallelism")
+}
+
+func encoderIds() []string {
+	ids := make([]string, 0, len(newEncoders))
+	for id := range newEncoders {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+var newEncoders = map[string]func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder{
+	"bcrypt": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		mode := password.LongPasswordReject
+		if f.bcryptPreHash {
+			mode = password.LongPasswordPreHash
+		}
+		return password.NewBCryptPasswordEncoder(f.bcryptCost,
+			password.WithBCryptVersion(password.BCryptVersion(f.bcryptVersion)),
+			password.WithLongPasswordMode(mode),
+		)
+	},
+	"pbkdf2": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		h := pbkdf2Hashes[f.pbkdf2Hash]
+		return password.NewPbkdf2PasswordEncoder(saltGen, f.pbkdf2Iterations, h().Size(), h)
+	},
+	"pbkdf2-sm3": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		return password.NewSm3Pbkdf2PasswordEncoder(saltGen, f.pbkdf2Sm3Iterations, sm3.Size)
+	},
+	"scrypt": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		return password.NewSCryptPasswordEncoder(saltGen, f.scryptN, f.scryptR, f.scryptP, 32)
+	},
+	"argon2": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		return password.NewArgon2PasswordEncoder(saltGen, 32, f.argon2Parallelism, f.argon2Memory, f.argon2Iterations)
+	},
+	"sha256": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		return password.NewSha256PasswordEncoder(saltGen)
+	},
+	"sm3": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		return password.NewSm3PasswordEncoder(saltGen)
+	},
+	"noop": func(f *encoderFlags, saltGen keygen.BytesKeyGenerator) password.PasswordEncoder {
+		return password.NopPasswordEncoder()
+	},
+}
+
+// delegating returns a DelegatingPasswordEncoder of all encoders, encoding with f.id
+func (f *encoderFlags) delegating(opts ...password.DelegatingOption) (*password.DelegatingPasswordEncoder, error) {
+	idToPasswordEncoder, err := f.encoders()
+	if err != nil {
+		return nil, err
+	}
+	return password.NewDelegatingPasswordEncoder(f.id, idToPasswordEncoder, opts...), nil
+}
+
+// onion returns the OnionPasswordEncoder wrapping legacyId in f.id
+func (f *encoderFlags) onion(legacyId string) (*password.OnionPasswordEncoder, error) {
+	idToPasswordEncoder, err := f.encoders()
+	if err != nil {
+		return nil, err
+	}
+	onion, ok := idToPasswordEncoder[onionId(legacyId, f.id)].(*password.OnionPasswordEncoder)
+	if !ok {
+		return nil, fmt.Errorf("cannot wrap %q in %q, want %s in a strong encoder", legacyId, f.id, strings.Join(legacyIds, " or "))
+	}
+	return onion, nil
+}
+
+// ids of the encoders an OnionPasswordEncoder can wrap
+var legacyIds = []string{"sha256", "sm3"}
+
+func onionId(legacyId, id string) string {
+	return legacyId + "-" + id
+}
+
+func (f *encoderFlags) encoders() (map[string]password.PasswordEncoder, error) {
+	if _, ok := newEncoders[f.id]; !ok {
+		return nil, fmt.Errorf("unknown id %q, want one of %s", f.id, strings.Join(encoderIds(), ", "))
+	}
+	if _, ok := pbkdf2Hashes[f.pbkdf2Hash]; !ok {
+		return nil, fmt.Errorf("unknown pbkdf2 hash %q", f.pbkdf2Hash)
+	}
+	switch password.BCryptVersion(f.bcryptVersion) {
+	case password.BCryptVersion2a, password.BCryptVersion2b, password.BCryptVersion2y:
+	default:
+		return nil, fmt.Errorf("unknown bcrypt version %q", f.bcryptVersion)
+	}
+	if f.saltLength < password.MinSaltLength {
+		return nil, fmt.Errorf("salt length %d is less than %d", f.saltLength, password.MinSaltLength)
+	}
+	if f.bcryptCost < bcrypt.MinCost || f.bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost %d must be in [%d, %d]", f.bcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if f.scryptN <= 1 || f.scryptN&(f.scryptN-1) != 0 {
+		return nil, fmt.Errorf("scrypt N %d must be a power of 2 greater than 1", f.scryptN)
+	}
+	if f.scryptR < 1 || f.scryptP < 1 {
+		return nil, fmt.Errorf("scrypt r %d and p %d must be positive", f.scryptR, f.scryptP)
+	}
+	if f.pbkdf2Iterations < 1 {
+		return nil, fmt.Errorf("pbkdf2 iterations %d must be positive", f.pbkdf2Iterations)
+	}
+	if f.pbkdf2Sm3Iterations < 1 {
+		return nil, fmt.Errorf("pbkdf2-sm3 iterations %d must be positive", f.pbkdf2Sm3Iterations)
+	}
+	if f.argon2Iterations < 1 {
+		return nil, fmt.Errorf("argon2 iterations %d must be positive", f.argon2Iterations)
+	}
+	if f.argon2Parallelism < 1 || f.argon2Parallelism > 255 {
+		return nil, fmt.Errorf("argon2 parallelism %d must be in [1, 255]", f.argon2Parallelism)
+	}
+	if f.argon2Memory < 8*f.argon2Parallelism {
+		return nil, fmt.Errorf("argon2 memory %d KiB is less than 8*parallelism", f.argon2Memory)
+	}
+
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(f.saltLength)
+	idToPasswordEncoder := make(map[string]password.PasswordEncoder, len(newEncoders))
+	for id, newEncoder := range newEncoders {
+		idToPasswordEncoder[id] = newEncoder(f, saltGen)
+	}
+	// onion encoders of rewrap, e.g. "sha256-bcrypt"
+	for _, legacyId := range legacyIds {
+		for id := range newEncoders {
+			if encoder := idToPasswordEncoder[id]; password.StrengthOf(encoder) == password.StrengthStrong {
+				idToPasswordEncoder[onionId(legacyId, id)] = password.NewOnionPasswordEncoder(idToPasswordEncoder[legacyId], encoder)
+			}
+		}
+	}
+	return idToPasswordEncoder, nil
+}
@@ -0,0 +1,315 @@
+// Command pwencoder encodes, verifies and inspects encoded passwords.
+//
+//	pwencoder encode [flags]                 encode a password, e.g. for a bootstrap admin
+//	pwencoder verify [flags] ENCODED         verify a password against ENCODED
+//	pwencoder inspect [-json] ENCODED        print the algorithm and parameters of ENCODED
+//	pwencoder upgrade-check [flags] ENCODED  report whether ENCODED should be re-encoded
+//	pwencoder audit [flags] FILE             classify the encoded passwords of a CSV or JSONL export, "-" for stdin
+//	pwencoder rewrap [flags] FILE            wrap legacy sha256 or sm3 hashes of an export in the -id encoder
+//
+// ENCODED is in DelegatingPasswordEncoder format, e.g. "{bcrypt}$2a$10$...".
+// Wrapped legacy hashes have ids like "{sha256-bcrypt}", see password.OnionPasswordEncoder.
+// The password is read from the terminal without echo, or from the first line of stdin.
+//
+// Exit status is 0 on success, 1 if the password does not match (verify) or should be upgraded
+// (upgrade-check, audit), 2 on usage errors, 3 if ENCODED (or a row of audit or rewrap) is malformed and 4 on other errors.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xuyang2/password-encoder/compromised"
+	"github.com/xuyang2/password-encoder/password"
+)
+
+const (
+	exitOK        = 0
+	exitMismatch  = 1 // verify: no match, upgrade-check and audit: upgrade needed
+	exitUsage     = 2
+	exitMalformed = 3
+	exitError     = 4
+)
+
+const usage = `usage: pwencoder <command> [flags] [ENCODED | FILE]
+
+commands:
+  encode         encode a password
+  verify         verify a password against ENCODED
+  inspect        print the algorithm and parameters of ENCODED
+  upgrade-check  report whether ENCODED should be re-encoded
+  audit          classify the encoded passwords of a CSV or JSONL export
+  rewrap         wrap the legacy hashes of a CSV or JSONL export in a strong encoder
+
+Run "pwencoder <command> -h" for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	commands := map[string]func(args []string, stdin io.Reader, stdout, stderr io.Writer) int{
+		"encode":        encode,
+		"verify":        verify,
+		"inspect":       inspect,
+		"upgrade-check": upgradeCheck,
+		"audit":         audit,
+		"rewrap":        rewrap,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		if args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+			fmt.Fprint(stdout, usage)
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "pwencoder: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	return command(args[1:], stdin, stdout, stderr)
+}
+
+var compromisedHashes = map[string]compromised.Hash{
+	"sha1": compromised.SHA1,
+	"ntlm": compromised.NTLM,
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("pwencoder "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parse returns the exit status for -h or invalid flags, or -1 to go on
+func parse(fs *flag.FlagSet, args []string, nArg int) int {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != nArg {
+		fmt.Fprintf(fs.Output(), "%s: want %d argument(s), got %d\n", fs.Name(), nArg, fs.NArg())
+		return exitUsage
+	}
+	return -1
+}
+
+func fail(stderr io.Writer, status int, err error) int {
+	fmt.Fprintf(stderr, "pwencoder: %v\n", err)
+	return status
+}
+
+func encode(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var f encoderFlags
+	fs := newFlagSet("encode", stderr)
+	f.register(fs)
+	raw := fs.Bool("raw", false, "omit the {id} prefix")
+	pwnedURL := fs.String("pwned-url", "", "reject passwords found by the Have I Been Pwned range API at this URL, e.g. "+compromised.DefaultPwnedPasswordsURL)
+	compromisedFile := fs.String("compromised-file", "", "reject passwords found in this sorted binary file of hashes")
+	compromisedHash := fs.String("compromised-hash", "sha1", "hash of -pwned-url and -compromised-file: sha1 or ntlm")
+	pwnedTimeout := fs.Duration("pwned-timeout", 10*time.Second, "timeout of the -pwned-url requests")
+	if status := parse(fs, args, 0); status >= 0 {
+		return status
+	}
+
+	delegating, err := f.delegating()
+	if err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+	var encoder password.BytesPasswordEncoder = delegating
+	hash, ok := compromisedHashes[*compromisedHash]
+	if !ok {
+		return fail(stderr, exitUsage, fmt.Errorf("unknown compromised hash %q", *compromisedHash))
+	}
+	if *pwnedURL != "" {
+		if *pwnedTimeout <= 0 {
+			return fail(stderr, exitUsage, fmt.Errorf("pwned timeout %v must be positive", *pwnedTimeout))
+		}
+		checker := compromised.NewPwnedPasswordsChecker(
+			compromised.WithBaseURL(*pwnedURL),
+			compromised.WithHash(hash),
+			compromised.WithHTTPClient(&http.Client{Timeout: *pwnedTimeout}),
+		)
+		encoder = compromised.NewCheckingPasswordEncoder(encoder, checker)
+	}
+	if *compromisedFile != "" {
+		checker, err := compromised.OpenFileChecker(*compromisedFile, hash)
+		if err != nil {
+			return fail(stderr, exitUsage, err)
+		}
+		defer checker.Close()
+		encoder = compromised.NewCheckingPasswordEncoder(encoder, checker)
+	}
+
+	rawPassword, err := readPassword(stdin, stderr, true)
+	if err != nil {
+		return fail(stderr, exitError, err)
+	}
+	defer rawPassword.Destroy()
+
+	encodedPassword, err := encoder.EncodeBytes(rawPassword.Bytes())
+	if err != nil {
+		return fail(stderr, exitError, err)
+	}
+	if *raw {
+		encodedPassword = strings.TrimPrefix(encodedPassword, password.DefaultIdPrefix+f.id+password.DefaultIdSuffix)
+	}
+	fmt.Fprintln(stdout, encodedPassword)
+	return exitOK
+}
+
+func verify(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var f encoderFlags
+	fs := newFlagSet("verify", stderr)
+	f.register(fs)
+	if status := parse(fs, args, 1); status >= 0 {
+		return status
+	}
+
+	encoder, err := f.delegating()
+	if err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+
+	encodedPassword := withId(fs.Arg(0), f.id)
+	if _, err := encoder.Inspect(encodedPassword); err != nil {
+		return fail(stderr, exitMalformed, err)
+	}
+
+	rawPassword, err := readPassword(stdin, stderr, false)
+	if err != nil {
+		return fail(stderr, exitError, err)
+	}
+	defer rawPassword.Destroy()
+
+	if !encoder.MatchesBytes(rawPassword.Bytes(), encodedPassword) {
+		fmt.Fprintln(stdout, "mismatch")
+		return exitMismatch
+	}
+	fmt.Fprintln(stdout, "match")
+	return exitOK
+}
+
+func inspect(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := newFlagSet("inspect", stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	if status := parse(fs, args, 1); status >= 0 {
+		return status
+	}
+
+	info, err := password.Inspect(fs.Arg(0))
+	if err != nil {
+		return fail(stderr, exitMalformed, err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			return fail(stderr, exitError, err)
+		}
+		return exitOK
+	}
+	printHashInfo(stdout, info)
+	return exitOK
+}
+
+func upgradeCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var f encoderFlags
+	fs := newFlagSet("upgrade-check", stderr)
+	f.register(fs)
+	minStrength := fs.String("min-strength", "unknown", "upgrade encoders classified below: insecure, legacy or strong")
+	if status := parse(fs, args, 1); status >= 0 {
+		return status
+	}
+
+	strength, err := parseStrength(*minStrength)
+	if err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+	encoder, err := f.delegating(password.WithMinStrength(strength))
+	if err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+
+	info, err := encoder.Inspect(withId(fs.Arg(0), f.id))
+	var unknownId *password.UnknownIdError
+	if errors.As(err, &unknownId) {
+		// not matched by any encoder, DelegatingPasswordEncoder.UpgradeEncoding is true
+		info, err = password.HashInfo{Id: unknownId.Id, UpgradeEncoding: true}, nil
+	}
+	if err != nil {
+		return fail(stderr, exitMalformed, err)
+	}
+
+	fmt.Fprintf(stdout, "upgrade: %t\n", info.UpgradeEncoding)
+	if info.UpgradeEncoding {
+		return exitMismatch
+	}
+	return exitOK
+}
+
+// withId prefixes encodedPassword with "{id}" unless it has an id
+func withId(encodedPassword string, id string) string {
+	if strings.HasPrefix(encodedPassword, password.DefaultIdPrefix) {
+		return encodedPassword
+	}
+	return password.DefaultIdPrefix + id + password.DefaultIdSuffix + encodedPassword
+}
+
+func parseStrength(s string) (password.Strength, error) {
+	for _, strength := range []password.Strength{password.StrengthUnknown, password.StrengthInsecure, password.StrengthLegacy, password.StrengthStrong} {
+		if s == strength.String() {
+			return strength, nil
+		}
+	}
+	return password.StrengthUnknown, fmt.Errorf("unknown strength %q", s)
+}
+
+func printHashInfo(w io.Writer, info password.HashInfo) {
+	field := func(name string, value interface{}) {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				return
+			}
+		case int:
+			if v == 0 {
+				return
+			}
+		}
+		fmt.Fprintf(w, "%-16s %v\n", name+":", value)
+	}
+
+	field("id", info.Id)
+	field("key id", info.KeyId)
+	field("algorithm", info.Algorithm)
+	field("candidates", strings.Join(info.Candidates, ", "))
+	field("strength", info.Strength.String())
+	field("version", info.Version)
+	field("cost", info.Cost)
+	field("block size", info.BlockSize)
+	field("memory", info.Memory)
+	field("iterations", info.Iterations)
+	field("parallelism", info.Parallelism)
+	field("salt length", info.SaltLength)
+	field("key length", info.KeyLength)
+	field("preparation", info.Preparation)
+	if info.Onion {
+		field("onion", info.Onion)
+	}
+	field("upgrade", info.UpgradeEncoding)
+}
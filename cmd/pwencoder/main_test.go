@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const springBCrypt = "{bcrypt}$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG" // "password"
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func runWith(stdin string, args ...string) (status int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	status = run(args, strings.NewReader(stdin), &out, &errOut)
+	return status, out.String(), errOut.String()
+}
+
+func TestRun(t *testing.T) {
+	status, _, stderr := runWith("")
+	assert.Equal(t, exitUsage, status)
+	assert.Contains(t, stderr, "usage:")
+
+	status, _, stderr = runWith("", "hash")
+	assert.Equal(t, exitUsage, status)
+	assert.Contains(t, stderr, `unknown command "hash"`)
+
+	status, stdout, _ := runWith("", "help")
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "upgrade-check")
+
+	status, _, _ = runWith("", "encode", "-h")
+	assert.Equal(t, exitOK, status)
+
+	status, _, _ = runWith("", "encode", "-no-such-flag")
+	assert.Equal(t, exitUsage, status)
+}
+
+func TestEncode(t *testing.T) {
+	for _, id := range encoderIds() {
+		t.Run(id, func(t *testing.T) {
+			flags := []string{"-id", id,
+				"-bcrypt-cost", "4", "-pbkdf2-iterations", "1", "-pbkdf2-sm3-iterations", "1",
+				"-scrypt-n", "16", "-argon2-memory", "64", "-argon2-iterations", "1"}
+			status, stdout, stderr := runWith("s3cret\n", append([]string{"encode"}, flags...)...)
+			require.Equal(t, exitOK, status, stderr)
+			encodedPassword := strings.TrimSuffix(stdout, "\n")
+			assert.True(t, strings.HasPrefix(encodedPassword, "{"+id+"}"))
+
+			// pbkdf2 iterations are not recorded in the encoded password
+			status, stdout, _ = runWith("s3cret\n", append(append([]string{"verify"}, flags...), encodedPassword)...)
+			assert.Equal(t, exitOK, status)
+			assert.Equal(t, "match\n", stdout)
+
+			status, stdout, _ = runWith("wrong\n", append(append([]string{"verify"}, flags...), encodedPassword)...)
+			assert.Equal(t, exitMismatch, status)
+			assert.Equal(t, "mismatch\n", stdout)
+		})
+	}
+
+	t.Run("raw", func(t *testing.T) {
+		status, stdout, _ := runWith("s3cret", "encode", "-raw", "-bcrypt-cost", "4")
+		require.Equal(t, exitOK, status)
+		assert.True(t, strings.HasPrefix(stdout, "$2a$04$"))
+	})
+
+	t.Run("bcrypt version", func(t *testing.T) {
+		status, stdout, _ := runWith("s3cret", "encode", "-raw", "-bcrypt-cost", "4", "-bcrypt-version", "2y")
+		require.Equal(t, exitOK, status)
+		assert.True(t, strings.HasPrefix(stdout, "$2y$04$"))
+
+		status, _, _ = runWith("s3cret", "encode", "-bcrypt-version", "2x")
+		assert.Equal(t, exitUsage, status)
+	})
+
+	t.Run("bcrypt long password", func(t *testing.T) {
+		long := strings.Repeat("a", 100)
+		status, _, stderr := runWith(long, "encode", "-bcrypt-cost", "4")
+		assert.Equal(t, exitError, status)
+		assert.Contains(t, stderr, "exceeds 72 bytes")
+
+		status, stdout, _ := runWith(long, "encode", "-bcrypt-cost", "4", "-bcrypt-prehash")
+		require.Equal(t, exitOK, status)
+		assert.True(t, strings.HasPrefix(stdout, "{bcrypt}$sha384$2a$04$"))
+
+		status, _, _ = runWith(long, "verify", strings.TrimSpace(stdout))
+		assert.Equal(t, exitOK, status)
+	})
+
+	t.Run("compromised", func(t *testing.T) {
+		// SHA-1 of "password"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n")
+		}))
+		defer server.Close()
+
+		status, _, stderr := runWith("password", "encode", "-pwned-url", server.URL+"/range/")
+		assert.Equal(t, exitError, status)
+		assert.Contains(t, stderr, "found in data breaches")
+
+		status, _, _ = runWith("s3cret", "encode", "-bcrypt-cost", "4", "-pwned-url", server.URL+"/range/")
+		assert.Equal(t, exitOK, status)
+
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer slow.Close()
+		status, _, _ = runWith("s3cret", "encode", "-pwned-url", slow.URL+"/range/", "-pwned-timeout", "10ms")
+		assert.Equal(t, exitError, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-pwned-url", server.URL+"/range/", "-pwned-timeout", "0s")
+		assert.Equal(t, exitUsage, status)
+
+		path := filepath.Join(t.TempDir(), "ntlm.bin")
+		require.NoError(t, os.WriteFile(path, mustDecodeHex("8846F7EAEE8FB117AD06BDD830B7586C"), 0o600)) // NTLM of "password"
+		status, _, stderr = runWith("password", "encode", "-compromised-file", path, "-compromised-hash", "ntlm")
+		assert.Equal(t, exitError, status)
+		assert.Contains(t, stderr, "found in data breaches")
+
+		status, _, _ = runWith("password", "encode", "-compromised-file", path)
+		assert.Equal(t, exitUsage, status, "not a multiple of the sha1 hash size")
+
+		status, _, _ = runWith("password", "encode", "-compromised-hash", "md5")
+		assert.Equal(t, exitUsage, status)
+	})
+
+	t.Run("invalid flags", func(t *testing.T) {
+		status, _, _ := runWith("s3cret", "encode", "-id", "md5")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "pbkdf2", "-pbkdf2-hash", "md5")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-salt-length", "4")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-bcrypt-cost", "2")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-bcrypt-cost", "32")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "scrypt", "-scrypt-n", "1000")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "scrypt", "-scrypt-n", "1")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "scrypt", "-scrypt-r", "0")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "scrypt", "-scrypt-p", "0")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "pbkdf2", "-pbkdf2-iterations", "0")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "pbkdf2-sm3", "-pbkdf2-sm3-iterations", "0")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "argon2", "-argon2-iterations", "0")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "argon2", "-argon2-parallelism", "0")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "argon2", "-argon2-parallelism", "256", "-argon2-memory", "4096")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "-id", "argon2", "-argon2-parallelism", "4", "-argon2-memory", "31")
+		assert.Equal(t, exitUsage, status)
+
+		status, _, _ = runWith("s3cret", "encode", "extra")
+		assert.Equal(t, exitUsage, status)
+	})
+
+	t.Run("empty stdin", func(t *testing.T) {
+		status, _, _ := runWith("", "encode")
+		assert.Equal(t, exitError, status)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	status, _, _ := runWith("password\r\n", "verify", springBCrypt)
+	assert.Equal(t, exitOK, status)
+
+	// no id, -id applies
+	status, _, _ = runWith("password\n", "verify", strings.TrimPrefix(springBCrypt, "{bcrypt}"))
+	assert.Equal(t, exitOK, status)
+
+	status, _, stderr := runWith("password\n", "verify", "{bcrypt}$2a$10$broken")
+	assert.Equal(t, exitMalformed, status)
+	assert.Contains(t, stderr, "unrecognized")
+
+	status, _, _ = runWith("password\n", "verify", "{md5}5f4dcc3b5aa765d61d8327deb882cf99")
+	assert.Equal(t, exitMalformed, status)
+
+	status, _, _ = runWith("password\n", "verify")
+	assert.Equal(t, exitUsage, status)
+}
+
+func TestInspect(t *testing.T) {
+	status, stdout, _ := runWith("", "inspect", springBCrypt)
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "algorithm:       bcrypt\n")
+	assert.Contains(t, stdout, "cost:            10\n")
+	assert.Contains(t, stdout, "upgrade:         false\n")
+
+	status, stdout, _ = runWith("", "inspect", "-json", springBCrypt)
+	assert.Equal(t, exitOK, status)
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &info))
+	assert.Equal(t, "bcrypt", info["algorithm"])
+	assert.Equal(t, "strong", info["strength"])
+
+	status, _, _ = runWith("", "inspect", "not a hash")
+	assert.Equal(t, exitMalformed, status)
+}
+
+func TestUpgradeCheck(t *testing.T) {
+	status, stdout, _ := runWith("", "upgrade-check", springBCrypt)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "upgrade: false\n", stdout)
+
+	status, stdout, _ = runWith("", "upgrade-check", "-id", "argon2", springBCrypt)
+	assert.Equal(t, exitMismatch, status)
+	assert.Equal(t, "upgrade: true\n", stdout)
+
+	status, _, _ = runWith("", "upgrade-check", "-id", "sha256", "-min-strength", "strong", "{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0")
+	assert.Equal(t, exitMismatch, status)
+
+	status, _, _ = runWith("", "upgrade-check", "-min-strength", "best", springBCrypt)
+	assert.Equal(t, exitUsage, status)
+
+	status, _, _ = runWith("", "upgrade-check", "{bcrypt}_")
+	assert.Equal(t, exitMalformed, status)
+
+	status, stdout, _ = runWith("", "upgrade-check", "{MD5}5f4dcc3b5aa765d61d8327deb882cf99")
+	assert.Equal(t, exitMismatch, status)
+	assert.Equal(t, "upgrade: true\n", stdout)
+}
+
+func TestAudit(t *testing.T) {
+	export := "id,password\n" +
+		"1," + springBCrypt + "\n" +
+		"2," + springBCrypt + "\n" +
+		"3,{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0\n" +
+		"4,{bcrypt}broken\n" +
+		"5,{MD5}5f4dcc3b5aa765d61d8327deb882cf99\n"
+
+	status, stdout, stderr := runWith(export, "audit", "-key", "id", "-")
+	assert.Equal(t, exitMalformed, status, stderr)
+	assert.Contains(t, stdout, "rows:            5\n")
+	assert.Contains(t, stdout, "upgrade:         2\n")
+	assert.Contains(t, stdout, "unknown id:      1\n")
+	assert.Contains(t, stdout, "malformed:       1\n")
+	assert.Contains(t, stdout, "         2  bcrypt       bcrypt       cost=10")
+	assert.Contains(t, stdout, "         1  MD5          unknown id")
+	assert.Contains(t, stdout, "  line 5 (4): ")
+	assert.NotContains(t, stdout, "broken")
+
+	status, stdout, _ = runWith(export[:strings.Index(export, "3,")], "audit", "-json", "-")
+	assert.Equal(t, exitOK, status)
+	var report struct {
+		Rows    int64
+		Classes []map[string]interface{}
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, int64(2), report.Rows)
+	assert.Equal(t, []map[string]interface{}{{"id": "bcrypt", "algorithm": "bcrypt", "cost": float64(10), "upgrade": false, "count": float64(2)}}, report.Classes)
+
+	status, _, _ = runWith(export, "audit", "-id", "argon2", "-min-strength", "strong", "-format", "csv", "-")
+	assert.Equal(t, exitMalformed, status)
+
+	status, _, _ = runWith(`{"password": "{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0"}`, "audit", "-format", "jsonl", "-")
+	assert.Equal(t, exitMismatch, status)
+
+	status, _, _ = runWith("", "audit", "-format", "xml", "-")
+	assert.Equal(t, exitUsage, status)
+
+	status, _, _ = runWith("", "audit", "no-such-file.csv")
+	assert.Equal(t, exitError, status)
+
+	status, _, stderr = runWith("id,hash\n", "audit", "-")
+	assert.Equal(t, exitError, status)
+	assert.Contains(t, stderr, `no column "password"`)
+}
+
+func TestRewrap(t *testing.T) {
+	status, legacy, _ := runWith("password\n", "encode", "-id", "sha256")
+	require.Equal(t, exitOK, status)
+	legacy = strings.TrimSuffix(legacy, "\n")
+
+	export := "id,password\n1," + legacy + "\n2," + springBCrypt + "\n3,{sha256}zz\n"
+	status, stdout, stderr := runWith(export, "rewrap", "-bcrypt-cost", "4", "-key", "id", "-")
+	assert.Equal(t, exitMalformed, status, stderr)
+	assert.Contains(t, stderr, "rewrapped:       1 (sha256-bcrypt)\n")
+	assert.Contains(t, stderr, "  line 4 (3): ")
+
+	lines := strings.Split(stdout, "\n")
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[1], "1,{sha256-bcrypt}"))
+	assert.Equal(t, "2,"+springBCrypt, lines[2])
+	assert.Equal(t, "3,{sha256}zz", lines[3])
+
+	wrapped := strings.TrimPrefix(lines[1], "1,")
+	status, stdout, _ = runWith("password\n", "verify", wrapped)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "match\n", stdout)
+
+	status, _, _ = runWith("", "upgrade-check", wrapped)
+	assert.Equal(t, exitMismatch, status)
+
+	status, stdout, _ = runWith("", "inspect", wrapped)
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "onion:")
+
+	status, _, stderr = runWith("", "rewrap", "-id", "sm3", "-")
+	assert.Equal(t, exitUsage, status)
+	assert.Contains(t, stderr, `cannot wrap "sha256" in "sm3"`)
+
+	status, _, _ = runWith("", "rewrap", "-from", "pbkdf2", "-")
+	assert.Equal(t, exitUsage, status)
+}
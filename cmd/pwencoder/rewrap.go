@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xuyang2/password-encoder/bulk"
+)
+
+func rewrap(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var f encoderFlags
+	fs := newFlagSet("rewrap", stderr)
+	f.register(fs)
+	from := fs.String("from", "sha256", "id of the legacy encoded passwords: sha256 or sm3")
+	format := fs.String("format", "csv", "export format: csv (with header) or jsonl")
+	field := fs.String("field", bulk.DefaultHashField, "column or field of the encoded password")
+	key := fs.String("key", "", "column or field identifying malformed rows, e.g. id")
+	maxMalformed := fs.Int("max-malformed", bulk.DefaultMaxMalformedRows, "malformed rows to list")
+	unprefixed := fs.Bool("unprefixed", false, "also wrap encoded passwords without {id} prefix")
+	workers := fs.Int("workers", 0, "concurrent encodings, GOMAXPROCS if 0")
+	output := fs.String("o", "-", "output file, - for stdout")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if status := parse(fs, args, 1); status >= 0 {
+		return status
+	}
+
+	opts := bulk.RewrapOptions{
+		HashField:        *field,
+		KeyField:         *key,
+		MaxMalformedRows: *maxMalformed,
+		FromId:           *from,
+		ToId:             onionId(*from, f.id),
+		Unprefixed:       *unprefixed,
+		Workers:          *workers,
+	}
+	var err error
+	if opts.Format, err = bulk.ParseFormat(*format); err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+	onion, err := f.onion(*from)
+	if err != nil {
+		return fail(stderr, exitUsage, err)
+	}
+
+	r := stdin
+	if name := fs.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return fail(stderr, exitError, err)
+		}
+		defer file.Close()
+		r = file
+	}
+	w := stdout
+	var file *os.File
+	if *output != "-" {
+		if file, err = os.Create(*output); err != nil {
+			return fail(stderr, exitError, err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	report, err := bulk.Rewrap(r, w, onion, opts)
+	if err != nil {
+		return fail(stderr, exitError, err)
+	}
+	if file != nil {
+		if err := file.Sync(); err != nil {
+			return fail(stderr, exitError, err)
+		}
+	}
+
+	// the report goes to stderr, stdout is the rewrapped export by default
+	if *asJSON {
+		enc := json.NewEncoder(stderr)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fail(stderr, exitError, err)
+		}
+	} else {
+		fmt.Fprintf(stderr, "%-16s %d\n", "rows:", report.Rows)
+		fmt.Fprintf(stderr, "%-16s %d (%s)\n", "rewrapped:", report.Rewrapped, opts.ToId)
+		fmt.Fprintf(stderr, "%-16s %d\n", "skipped:", report.Skipped)
+		fmt.Fprintf(stderr, "%-16s %d\n", "malformed:", report.Malformed)
+		printMalformedRows(stderr, report.MalformedRows, report.Malformed)
+	}
+
+	if report.Malformed > 0 {
+		return exitMalformed
+	}
+	return exitOK
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/xuyang2/password-encoder/password"
+)
+
+// readPassword reads the password from the terminal without echo, prompting on stderr,
+// or else the first line of stdin. confirm asks the terminal for the password twice.
+// The caller destroys the returned password.
+func readPassword(stdin io.Reader, stderr io.Writer, confirm bool) (*password.RawPassword, error) {
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		rawPassword, err := promptPassword(f, stderr, "Password: ")
+		if err != nil {
+			return nil, err
+		}
+		if confirm {
+			retyped, err := promptPassword(f, stderr, "Retype password: ")
+			if err != nil {
+				rawPassword.Destroy()
+				return nil, err
+			}
+			defer retyped.Destroy()
+			if subtle.ConstantTimeCompare(retyped.Bytes(), rawPassword.Bytes()) != 1 {
+				rawPassword.Destroy()
+				return nil, errors.New("passwords do not match")
+			}
+		}
+		return rawPassword, nil
+	}
+
+	line, err := bufio.NewReader(stdin).ReadSlice('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return nil, fmt.Errorf("reading password from stdin: %w", err)
+	}
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+	rawPassword := password.NewRawPassword(append([]byte(nil), line...))
+	zero(line)
+	return rawPassword, nil
+}
+
+func promptPassword(tty *os.File, stderr io.Writer, prompt string) (*password.RawPassword, error) {
+	fmt.Fprint(stderr, prompt)
+	defer fmt.Fprintln(stderr)
+
+	rawPassword, err := term.ReadPassword(int(tty.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	return password.NewRawPassword(rawPassword), nil
+}
+
+// zero overwrites the bufio buffer holding the password
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,66 @@
+package compromised
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// ErrCompromisedPassword is returned by CheckingPasswordEncoder for raw passwords found in data breaches
+var ErrCompromisedPassword = errors.New("compromised: password found in data breaches")
+
+// Decision is the result of a Checker, Count is the number of breaches if known
+type Decision struct {
+	Compromised bool
+	Count       int64
+}
+
+// Checker checks whether a raw password is compromised.
+//
+// https://docs.spring.io/spring-security/site/docs/6.3.0/api/org/springframework/security/authentication/password/CompromisedPasswordChecker.html
+type Checker interface {
+	Check(ctx context.Context, rawPassword string) (Decision, error)
+}
+
+// Hash is the hash function of the compromised password hashes
+type Hash int
+
+const (
+	SHA1 Hash = iota // SHA-1 of the UTF-8 password
+	NTLM             // MD4 of the UTF-16LE password
+)
+
+func (h Hash) String() string {
+	switch h {
+	case SHA1:
+		return "sha1"
+	case NTLM:
+		return "ntlm"
+	default:
+		return fmt.Sprintf("Hash(%d)", int(h))
+	}
+}
+
+// Size returns the length of the hashes in bytes
+func (h Hash) Size() int {
+	if h == NTLM {
+		return md4.Size
+	}
+	return sha1.Size
+}
+
+func (h Hash) sum(rawPassword string) []byte {
+	if h == NTLM {
+		d := md4.New()
+		for _, u := range utf16.Encode([]rune(rawPassword)) {
+			d.Write([]byte{byte(u), byte(u >> 8)})
+		}
+		return d.Sum(nil)
+	}
+	sum := sha1.Sum([]byte(rawPassword))
+	return sum[:]
+}
@@ -0,0 +1,28 @@
+package compromised
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sha1Password = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8" // "password"
+	ntlmPassword = "8846F7EAEE8FB117AD06BDD830B7586C"         // "password"
+)
+
+func upperHex(b []byte) string {
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, sha1Password, upperHex(SHA1.sum("password")))
+	assert.Equal(t, ntlmPassword, upperHex(NTLM.sum("password")))
+
+	assert.Equal(t, 20, SHA1.Size())
+	assert.Equal(t, 16, NTLM.Size())
+	assert.Equal(t, "ntlm", NTLM.String())
+	assert.Equal(t, "Hash(7)", Hash(7).String())
+}
@@ -0,0 +1,91 @@
+package compromised
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xuyang2/password-encoder/password"
+)
+
+// CheckingPasswordEncoder refuses to encode compromised raw passwords before delegating to another PasswordEncoder,
+// returning an error wrapping ErrCompromisedPassword, or the error of the Checker.
+// Matches does not check, so that users with compromised passwords can still log in and change them,
+// and neither does EncodeVerified, so that password.MatchAndUpgrade upgrades their encoding.
+type CheckingPasswordEncoder struct {
+	delegate password.PasswordEncoder
+	checker  Checker
+}
+
+var _ password.ContextPasswordEncoder = (*CheckingPasswordEncoder)(nil)
+var _ password.BytesPasswordEncoder = (*CheckingPasswordEncoder)(nil)
+var _ password.VerifiedEncoder = (*CheckingPasswordEncoder)(nil)
+
+func NewCheckingPasswordEncoder(delegate password.PasswordEncoder, checker Checker) *CheckingPasswordEncoder {
+	return &CheckingPasswordEncoder{
+		delegate: delegate,
+		checker:  checker,
+	}
+}
+
+func (e *CheckingPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *CheckingPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	if err := e.check(ctx, rawPassword); err != nil {
+		return "", err
+	}
+	return password.EncodeContext(ctx, e.delegate, rawPassword)
+}
+
+// EncodeBytes checks a string copy of rawPassword, which cannot be zeroed
+func (e *CheckingPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	if err := e.check(context.Background(), string(rawPassword)); err != nil {
+		return "", err
+	}
+	return password.EncodeBytes(e.delegate, rawPassword)
+}
+
+// EncodeVerified encodes rawPassword without checking it, see password.VerifiedEncoder
+func (e *CheckingPasswordEncoder) EncodeVerified(rawPassword string) (string, error) {
+	return password.EncodeVerified(e.delegate, rawPassword)
+}
+
+func (e *CheckingPasswordEncoder) check(ctx context.Context, rawPassword string) error {
+	decision, err := e.checker.Check(ctx, rawPassword)
+	if err != nil {
+		return err
+	}
+	if decision.Compromised {
+		if decision.Count > 0 {
+			return fmt.Errorf("%w %d times", ErrCompromisedPassword, decision.Count)
+		}
+		return ErrCompromisedPassword
+	}
+	return nil
+}
+
+func (e *CheckingPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	return e.delegate.Matches(rawPassword, encodedPassword)
+}
+
+func (e *CheckingPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return password.MatchesContext(ctx, e.delegate, rawPassword, encodedPassword)
+}
+
+func (e *CheckingPasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	return password.MatchesBytes(e.delegate, rawPassword, encodedPassword)
+}
+
+func (e *CheckingPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	return e.delegate.UpgradeEncoding(encodedPassword)
+}
+
+// the Strength of the delegate
+func (e *CheckingPasswordEncoder) Strength() password.Strength {
+	return password.StrengthOf(e.delegate)
+}
+
+func (e *CheckingPasswordEncoder) EstimateMemory(encodedPassword string) int64 {
+	return password.EstimateMemory(e.delegate, encodedPassword)
+}
@@ -0,0 +1,92 @@
+package compromised
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/password"
+)
+
+type checkerFunc func(ctx context.Context, rawPassword string) (Decision, error)
+
+func (f checkerFunc) Check(ctx context.Context, rawPassword string) (Decision, error) {
+	return f(ctx, rawPassword)
+}
+
+func TestCheckingPasswordEncoder(t *testing.T) {
+	server, _ := newPwnedServer(t)
+	encoder := NewCheckingPasswordEncoder(password.NopPasswordEncoder(), NewPwnedPasswordsChecker(WithBaseURL(server.URL+"/range/")))
+
+	t.Run("encode", func(t *testing.T) {
+		encodedPassword, err := encoder.Encode("correct horse battery staple")
+		require.NoError(t, err)
+		assert.Equal(t, "correct horse battery staple", encodedPassword)
+
+		_, err = encoder.Encode("password")
+		assert.True(t, errors.Is(err, ErrCompromisedPassword))
+		assert.EqualError(t, err, "compromised: password found in data breaches 3861493 times")
+
+		_, err = encoder.EncodeBytes([]byte("password"))
+		assert.True(t, errors.Is(err, ErrCompromisedPassword))
+	})
+
+	t.Run("matches compromised passwords", func(t *testing.T) {
+		assert.True(t, encoder.Matches("password", "password"))
+		assert.True(t, encoder.MatchesBytes([]byte("password"), "password"))
+		matched, err := encoder.MatchesContext(context.Background(), "password", "password")
+		require.NoError(t, err)
+		assert.True(t, matched)
+	})
+
+	t.Run("checker error", func(t *testing.T) {
+		failing := NewCheckingPasswordEncoder(password.NopPasswordEncoder(), checkerFunc(func(ctx context.Context, rawPassword string) (Decision, error) {
+			return Decision{}, errors.New("unavailable")
+		}))
+		_, err := failing.Encode("correct horse battery staple")
+		assert.EqualError(t, err, "unavailable")
+	})
+
+	t.Run("without count", func(t *testing.T) {
+		compromised := NewCheckingPasswordEncoder(password.NopPasswordEncoder(), checkerFunc(func(ctx context.Context, rawPassword string) (Decision, error) {
+			return Decision{Compromised: true}, nil
+		}))
+		_, err := compromised.EncodeContext(context.Background(), "password")
+		assert.Equal(t, ErrCompromisedPassword, err)
+	})
+
+	t.Run("upgrade compromised passwords", func(t *testing.T) {
+		legacy := password.NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16))
+		delegate := password.NewDelegatingPasswordEncoder("bcrypt", map[string]password.PasswordEncoder{
+			"bcrypt": password.NewBCryptPasswordEncoder(bcrypt.MinCost),
+			"sha256": legacy,
+		})
+		encodedPassword, err := legacy.Encode("password")
+		require.NoError(t, err)
+
+		for _, decision := range []error{nil, ErrCompromisedPassword, errors.New("unavailable")} {
+			var checked bool
+			encoder := NewCheckingPasswordEncoder(delegate, checkerFunc(func(ctx context.Context, rawPassword string) (Decision, error) {
+				checked = true
+				return Decision{Compromised: decision == ErrCompromisedPassword}, decision
+			}))
+			matched, newEncodedPassword, err := password.MatchAndUpgrade(encoder, "password", "{sha256}"+encodedPassword)
+			assert.True(t, matched)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(newEncodedPassword, "{bcrypt}"))
+			assert.True(t, encoder.Matches("password", newEncodedPassword))
+			assert.False(t, checked)
+		}
+	})
+
+	t.Run("delegate", func(t *testing.T) {
+		assert.Equal(t, password.StrengthInsecure, encoder.Strength())
+		assert.Equal(t, password.NopPasswordEncoder().UpgradeEncoding("password"), encoder.UpgradeEncoding("password"))
+	})
+}
@@ -0,0 +1,142 @@
+package compromised
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FileChecker checks passwords offline against a file of sorted raw hashes of Hash.Size() bytes each,
+// by binary search with Hash.Size() bytes reads. See WriteHashFile.
+type FileChecker struct {
+	r    io.ReaderAt
+	n    int64 // number of hashes
+	hash Hash
+
+	closer io.Closer // the file of OpenFileChecker
+}
+
+var _ Checker = (*FileChecker)(nil)
+
+// NewFileChecker checks passwords against r of size bytes, which must be a multiple of hash.Size()
+func NewFileChecker(r io.ReaderAt, size int64, hash Hash) (*FileChecker, error) {
+	if hash != SHA1 && hash != NTLM {
+		return nil, fmt.Errorf("compromised: unknown hash %v", hash)
+	}
+	if size%int64(hash.Size()) != 0 {
+		return nil, fmt.Errorf("compromised: file size %d is not a multiple of the %s hash size %d", size, hash, hash.Size())
+	}
+	return &FileChecker{r: r, n: size / int64(hash.Size()), hash: hash}, nil
+}
+
+// OpenFileChecker checks passwords against the file at path, the caller calls Close
+func OpenFileChecker(path string, hash Hash) (*FileChecker, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	c, err := NewFileChecker(f, fi.Size(), hash)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	c.closer = f
+	return c, nil
+}
+
+// Close closes the file of OpenFileChecker
+func (c *FileChecker) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+	return c.closer.Close()
+}
+
+// Check returns a Decision without Count
+func (c *FileChecker) Check(ctx context.Context, rawPassword string) (Decision, error) {
+	if err := ctx.Err(); err != nil {
+		return Decision{}, err
+	}
+	sum := c.hash.sum(rawPassword)
+
+	var readErr error
+	record := make([]byte, c.hash.Size())
+	i := sort.Search(int(c.n), func(i int) bool {
+		if readErr != nil {
+			return true
+		}
+		if err := c.readRecord(record, i); err != nil {
+			readErr = err
+			return true
+		}
+		return bytes.Compare(record, sum) >= 0
+	})
+	if readErr != nil {
+		return Decision{}, fmt.Errorf("compromised: %w", readErr)
+	}
+	if i == int(c.n) {
+		return Decision{}, nil
+	}
+	if err := c.readRecord(record, i); err != nil {
+		return Decision{}, fmt.Errorf("compromised: %w", err)
+	}
+	return Decision{Compromised: bytes.Equal(record, sum)}, nil
+}
+
+// readRecord reads the i-th hash, ReaderAt may return io.EOF with the last one
+func (c *FileChecker) readRecord(record []byte, i int) error {
+	n, err := c.r.ReadAt(record, int64(i)*int64(len(record)))
+	if errors.Is(err, io.EOF) && n == len(record) {
+		return nil
+	}
+	return err
+}
+
+// WriteHashFile converts the "HASH:COUNT" or "HASH" lines of hex hashes from r, e.g. as downloaded
+// from Have I Been Pwned, to the file format of FileChecker. The lines must be sorted, duplicates are written once.
+func WriteHashFile(r io.Reader, w io.Writer, hash Hash) (int64, error) {
+	bw := bufio.NewWriter(w)
+	scanner := bufio.NewScanner(r)
+	var prev []byte
+	var n, line int64
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		hexHash, _, _ := strings.Cut(text, ":")
+		sum, err := hex.DecodeString(hexHash)
+		if err != nil || len(sum) != hash.Size() {
+			return n, fmt.Errorf("compromised: line %d: not a %s hash", line, hash)
+		}
+
+		switch cmp := bytes.Compare(prev, sum); {
+		case prev != nil && cmp > 0:
+			return n, fmt.Errorf("compromised: line %d: hashes are not sorted", line)
+		case prev != nil && cmp == 0:
+			continue
+		}
+		if _, err := bw.Write(sum); err != nil {
+			return n, err
+		}
+		prev = sum
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
@@ -0,0 +1,116 @@
+package compromised
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eofReaderAt returns io.EOF with reads up to the end, as io.ReaderAt allows
+type eofReaderAt struct {
+	r *bytes.Reader
+}
+
+func (r eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.r.ReadAt(p, off)
+	if err == nil && off+int64(n) == r.r.Size() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestFileChecker(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		hash  Hash
+		lines string
+	}{
+		{
+			hash: SHA1,
+			lines: "0000000A0E3B9F25FF41DE4B5AC238C2D545C7A8:15\n" +
+				"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n" +
+				"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:3861493\n" + // duplicate
+				"\n" +
+				"FFFFFFFEE791CBAC0F6305CAF0CEE06BBE131160:2\n",
+		},
+		{
+			hash: NTLM,
+			lines: "00000000000000000000000000000001\n" +
+				"8846F7EAEE8FB117AD06BDD830B7586C\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hash.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := WriteHashFile(strings.NewReader(tt.lines), &buf, tt.hash)
+			require.NoError(t, err)
+			assert.EqualValues(t, buf.Len()/tt.hash.Size(), n)
+
+			path := filepath.Join(t.TempDir(), "hashes.bin")
+			require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+			checker, err := OpenFileChecker(path, tt.hash)
+			require.NoError(t, err)
+			defer checker.Close()
+
+			decision, err := checker.Check(ctx, "password")
+			require.NoError(t, err)
+			assert.True(t, decision.Compromised)
+
+			for _, rawPassword := range []string{"correct horse battery staple", "", "\xff"} {
+				decision, err = checker.Check(ctx, rawPassword)
+				require.NoError(t, err)
+				assert.False(t, decision.Compromised, rawPassword)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		checker, err := NewFileChecker(bytes.NewReader(nil), 0, SHA1)
+		require.NoError(t, err)
+		decision, err := checker.Check(ctx, "password")
+		require.NoError(t, err)
+		assert.False(t, decision.Compromised)
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		_, err := NewFileChecker(bytes.NewReader(make([]byte, 21)), 21, SHA1)
+		assert.Error(t, err)
+
+		_, err = OpenFileChecker(filepath.Join(t.TempDir(), "missing"), SHA1)
+		assert.Error(t, err)
+	})
+
+	t.Run("eof with last record", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := WriteHashFile(strings.NewReader("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n"), &buf, SHA1)
+		require.NoError(t, err)
+		checker, err := NewFileChecker(eofReaderAt{bytes.NewReader(buf.Bytes())}, int64(buf.Len()), SHA1)
+		require.NoError(t, err)
+		decision, err := checker.Check(ctx, "password")
+		require.NoError(t, err)
+		assert.True(t, decision.Compromised)
+	})
+
+	t.Run("short read", func(t *testing.T) {
+		checker, err := NewFileChecker(bytes.NewReader(make([]byte, 20)), 40, SHA1)
+		require.NoError(t, err)
+		_, err = checker.Check(ctx, "password")
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteHashFile(t *testing.T) {
+	_, err := WriteHashFile(strings.NewReader("FFFFFFFEE791CBAC0F6305CAF0CEE06BBE131160\n0000000A0E3B9F25FF41DE4B5AC238C2D545C7A8\n"), &bytes.Buffer{}, SHA1)
+	assert.ErrorContains(t, err, "line 2: hashes are not sorted")
+
+	_, err = WriteHashFile(strings.NewReader("8846F7EAEE8FB117AD06BDD830B7586C\n"), &bytes.Buffer{}, SHA1)
+	assert.ErrorContains(t, err, "line 1: not a sha1 hash")
+}
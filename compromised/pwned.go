@@ -0,0 +1,143 @@
+package compromised
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultPwnedPasswordsURL is the range API of Have I Been Pwned
+const DefaultPwnedPasswordsURL = "https://api.pwnedpasswords.com/range/"
+
+// the first 5 hex digits of the hash are sent
+const pwnedPrefixLen = 5
+
+// maxPwnedResponseSize limits the response body, about 2k lines of 40 bytes with padding
+const maxPwnedResponseSize = 1 << 20
+
+type PwnedOption func(c *PwnedPasswordsChecker)
+
+// WithBaseURL queries baseURL + prefix instead of DefaultPwnedPasswordsURL,
+// e.g. a local mirror of the range API or a test server.
+func WithBaseURL(baseURL string) PwnedOption {
+	return func(c *PwnedPasswordsChecker) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sends the requests with client instead of http.DefaultClient, which has no timeout
+func WithHTTPClient(client *http.Client) PwnedOption {
+	return func(c *PwnedPasswordsChecker) {
+		c.client = client
+	}
+}
+
+// WithHash queries NTLM hashes with "?mode=ntlm", SHA1 by default
+func WithHash(hash Hash) PwnedOption {
+	return func(c *PwnedPasswordsChecker) {
+		c.hash = hash
+	}
+}
+
+// WithoutPadding does not ask for padded responses, which hide the number of suffixes of a prefix
+// from an observer of the response size.
+func WithoutPadding() PwnedOption {
+	return func(c *PwnedPasswordsChecker) {
+		c.padding = false
+	}
+}
+
+// PwnedPasswordsChecker checks passwords with the Have I Been Pwned range API, sending only the first
+// 5 hex digits of the hash (k-anonymity) and asking for padded responses.
+//
+// https://haveibeenpwned.com/API/v3#PwnedPasswords
+type PwnedPasswordsChecker struct {
+	baseURL string
+	client  *http.Client
+	hash    Hash
+	padding bool
+}
+
+var _ Checker = (*PwnedPasswordsChecker)(nil)
+
+// panics if the hash is unknown
+func NewPwnedPasswordsChecker(opts ...PwnedOption) *PwnedPasswordsChecker {
+	c := &PwnedPasswordsChecker{
+		baseURL: DefaultPwnedPasswordsURL,
+		client:  http.DefaultClient,
+		hash:    SHA1,
+		padding: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.hash != SHA1 && c.hash != NTLM {
+		panic(fmt.Errorf("unknown hash %v", c.hash))
+	}
+	return c
+}
+
+func (c *PwnedPasswordsChecker) Check(ctx context.Context, rawPassword string) (Decision, error) {
+	hash := strings.ToUpper(hex.EncodeToString(c.hash.sum(rawPassword)))
+	prefix, suffix := hash[:pwnedPrefixLen], hash[pwnedPrefixLen:]
+
+	url := c.baseURL + prefix
+	if c.hash == NTLM {
+		url += "?mode=ntlm"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Decision{}, err
+	}
+	if c.padding {
+		req.Header.Set("Add-Padding", "true")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("compromised: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("compromised: range request for %s: %s", prefix, resp.Status)
+	}
+
+	body := &io.LimitedReader{R: resp.Body, N: maxPwnedResponseSize + 1}
+	decision, err := findSuffix(body, suffix)
+	if body.N == 0 { // the last line may be cut and malformed
+		return Decision{}, fmt.Errorf("compromised: range response for %s exceeds %d bytes", prefix, maxPwnedResponseSize)
+	}
+	return decision, err
+}
+
+// findSuffix finds the suffix in "SUFFIX:COUNT" lines, ignoring the padding lines with count 0
+func findSuffix(r io.Reader, suffix string) (Decision, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		s, countText, ok := strings.Cut(line, ":")
+		if !ok {
+			return Decision{}, fmt.Errorf("compromised: malformed range response line %q", line)
+		}
+		if !strings.EqualFold(s, suffix) {
+			continue
+		}
+		count, err := strconv.ParseInt(countText, 10, 64)
+		if err != nil {
+			return Decision{}, fmt.Errorf("compromised: malformed range response line %q", line)
+		}
+		return Decision{Compromised: count > 0, Count: count}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return Decision{}, fmt.Errorf("compromised: %w", err)
+	}
+	return Decision{}, nil
+}
@@ -0,0 +1,118 @@
+package compromised
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a range API with "password" and padding
+func newPwnedServer(t *testing.T) (*httptest.Server, *[]*http.Request) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		hash := sha1Password
+		if r.URL.Query().Get("mode") == "ntlm" {
+			hash = ntlmPassword
+		}
+		if !strings.HasSuffix(r.URL.Path, "/"+hash[:5]) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		fmt.Fprint(w, "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n")
+		fmt.Fprintf(w, "%s:3861493\r\n", strings.ToLower(hash[5:]))
+		if r.Header.Get("Add-Padding") == "true" {
+			fmt.Fprint(w, "00D4F6E8FA6EECAD2A3AA415EEC418D38EC:0\r\n")
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestPwnedPasswordsChecker(t *testing.T) {
+	server, requests := newPwnedServer(t)
+	ctx := context.Background()
+
+	t.Run("sha1", func(t *testing.T) {
+		checker := NewPwnedPasswordsChecker(WithBaseURL(server.URL + "/range/"))
+
+		decision, err := checker.Check(ctx, "password")
+		require.NoError(t, err)
+		assert.Equal(t, Decision{Compromised: true, Count: 3861493}, decision)
+
+		r := (*requests)[len(*requests)-1]
+		assert.Equal(t, "/range/5BAA6", r.URL.Path)
+		assert.Equal(t, "true", r.Header.Get("Add-Padding"))
+
+		decision, err = checker.Check(ctx, "correct horse battery staple")
+		require.NoError(t, err)
+		assert.Equal(t, Decision{}, decision)
+	})
+
+	t.Run("ntlm", func(t *testing.T) {
+		checker := NewPwnedPasswordsChecker(WithBaseURL(server.URL+"/range/"), WithHash(NTLM), WithoutPadding())
+
+		decision, err := checker.Check(ctx, "password")
+		require.NoError(t, err)
+		assert.True(t, decision.Compromised)
+
+		r := (*requests)[len(*requests)-1]
+		assert.Equal(t, "/range/8846F", r.URL.Path)
+		assert.Equal(t, "mode=ntlm", r.URL.RawQuery)
+		assert.Empty(t, r.Header.Get("Add-Padding"))
+	})
+
+	t.Run("padding", func(t *testing.T) {
+		decision, err := findSuffix(strings.NewReader("00D4F6E8FA6EECAD2A3AA415EEC418D38EC:0\n"), "00D4F6E8FA6EECAD2A3AA415EEC418D38EC")
+		require.NoError(t, err)
+		assert.False(t, decision.Compromised)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := findSuffix(strings.NewReader("garbage\n"), "00D4F6E8FA6EECAD2A3AA415EEC418D38EC")
+		assert.Error(t, err)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer unavailable.Close()
+
+		_, err := NewPwnedPasswordsChecker(WithBaseURL(unavailable.URL+"/")).Check(ctx, "password")
+		assert.ErrorContains(t, err, "503")
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			line := "00D4F6E8FA6EECAD2A3AA415EEC418D38EC:0\r\n"
+			fmt.Fprint(w, strings.Repeat(line, maxPwnedResponseSize/len(line)+1))
+		}))
+		defer large.Close()
+
+		_, err := NewPwnedPasswordsChecker(WithBaseURL(large.URL+"/")).Check(ctx, "password")
+		assert.ErrorContains(t, err, "exceeds")
+	})
+
+	t.Run("client", func(t *testing.T) {
+		client := &http.Client{Timeout: time.Second}
+		checker := NewPwnedPasswordsChecker(WithBaseURL(server.URL+"/range/"), WithHTTPClient(client))
+		assert.Same(t, client, checker.client)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := checker.Check(canceled, "password")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("unknown hash", func(t *testing.T) {
+		assert.Panics(t, func() { NewPwnedPasswordsChecker(WithHash(Hash(7))) })
+	})
+}
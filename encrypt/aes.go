@@ -0,0 +1,143 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+type CipherAlgorithm int
+
+const (
+	CBC CipherAlgorithm = iota // AES/CBC/PKCS5Padding
+	GCM                        // AES/GCM/NoPadding
+)
+
+const (
+	keyIterations = 1024
+	keyLength     = 32 // 256 bits
+)
+
+var errInvalidPadding = errors.New("encrypt: invalid padding")
+
+// AesBytesEncryptor is byte-compatible with Spring's AesBytesEncryptor.
+//
+// The secret key is derived with PBKDF2WithHmacSHA1 (1024 iterations, 256 bits)
+// from the password and hex-encoded salt. The encrypted bytes are iv + ciphertext.
+//
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/encrypt/AesBytesEncryptor.html
+type AesBytesEncryptor struct {
+	block    cipher.Block
+	ivGen    keygen.BytesKeyGenerator
+	ivLength int
+	alg      CipherAlgorithm
+}
+
+var _ BytesEncryptor = (*AesBytesEncryptor)(nil)
+
+func NewAesBytesEncryptor(password, salt string, ivGen keygen.BytesKeyGenerator, alg CipherAlgorithm) (*AesBytesEncryptor, error) {
+	saltBytes, err := hex.DecodeString(salt)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: salt must be hex-encoded: %w", err)
+	}
+	key := pbkdf2.Key([]byte(password), saltBytes, keyIterations, keyLength, sha1.New)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if alg != CBC && alg != GCM {
+		return nil, fmt.Errorf("encrypt: unknown CipherAlgorithm %d", alg)
+	}
+
+	return &AesBytesEncryptor{
+		block:    block,
+		ivGen:    ivGen,
+		ivLength: ivGen.KeyLength(),
+		alg:      alg,
+	}, nil
+}
+
+func (e *AesBytesEncryptor) Encrypt(bytes []byte) ([]byte, error) {
+	iv, err := e.ivGen.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != e.ivLength {
+		return nil, fmt.Errorf("encrypt: iv length %d, want %d", len(iv), e.ivLength)
+	}
+
+	switch e.alg {
+	case GCM:
+		aead, err := cipher.NewGCMWithNonceSize(e.block, len(iv))
+		if err != nil {
+			return nil, err
+		}
+		return aead.Seal(iv, iv, bytes, nil), nil
+	default:
+		if len(iv) != e.block.BlockSize() {
+			return nil, fmt.Errorf("encrypt: iv length %d, want %d", len(iv), e.block.BlockSize())
+		}
+		padded := pkcs5Pad(bytes, e.block.BlockSize())
+		encrypted := make([]byte, len(iv)+len(padded))
+		copy(encrypted, iv)
+		cipher.NewCBCEncrypter(e.block, iv).CryptBlocks(encrypted[len(iv):], padded)
+		return encrypted, nil
+	}
+}
+
+func (e *AesBytesEncryptor) Decrypt(encryptedBytes []byte) ([]byte, error) {
+	if len(encryptedBytes) < e.ivLength {
+		return nil, errors.New("encrypt: encrypted bytes too short")
+	}
+	iv, encrypted := encryptedBytes[:e.ivLength], encryptedBytes[e.ivLength:]
+
+	switch e.alg {
+	case GCM:
+		aead, err := cipher.NewGCMWithNonceSize(e.block, len(iv))
+		if err != nil {
+			return nil, err
+		}
+		return aead.Open(nil, iv, encrypted, nil)
+	default:
+		if len(iv) != e.block.BlockSize() {
+			return nil, fmt.Errorf("encrypt: iv length %d, want %d", len(iv), e.block.BlockSize())
+		}
+		if len(encrypted) == 0 || len(encrypted)%e.block.BlockSize() != 0 {
+			return nil, errors.New("encrypt: encrypted bytes not a multiple of the block size")
+		}
+		decrypted := make([]byte, len(encrypted))
+		cipher.NewCBCDecrypter(e.block, iv).CryptBlocks(decrypted, encrypted)
+		return pkcs5Unpad(decrypted, e.block.BlockSize())
+	}
+}
+
+func pkcs5Pad(b []byte, blockSize int) []byte {
+	n := blockSize - len(b)%blockSize
+	return append(append(make([]byte, 0, len(b)+n), b...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func pkcs5Unpad(b []byte, blockSize int) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, errInvalidPadding
+	}
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize || n > len(b) {
+		return nil, errInvalidPadding
+	}
+	for _, p := range b[len(b)-n:] {
+		if int(p) != n {
+			return nil, errInvalidPadding
+		}
+	}
+	return b[:len(b)-n], nil
+}
@@ -0,0 +1,137 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/keygen/keygentest"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+var (
+	password = "password"
+	hexSalt  = hex.EncodeToString([]byte("salt"))
+	ivGen    = keygentest.FixedBytesKeyGenerator(mustDecodeHex("4b0febebd439db7ca77153cb254520c3"))
+)
+
+func TestNewAesBytesEncryptor(t *testing.T) {
+	t.Run("salt not hex", func(t *testing.T) {
+		_, err := NewAesBytesEncryptor(password, "salt", ivGen, CBC)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown alg", func(t *testing.T) {
+		_, err := NewAesBytesEncryptor(password, hexSalt, ivGen, CipherAlgorithm(42))
+		assert.Error(t, err)
+	})
+}
+
+func TestAesBytesEncryptor_Encrypt(t *testing.T) {
+	// new AesBytesEncryptor("password", new String(Hex.encode("salt".getBytes())), ivGen, alg).encrypt("value".getBytes())
+	t.Run("cbc", func(t *testing.T) {
+		encryptor, err := NewAesBytesEncryptor(password, hexSalt, ivGen, CBC)
+		require.NoError(t, err)
+
+		encrypted, err := encryptor.Encrypt([]byte("value"))
+		assert.NoError(t, err)
+		assert.Equal(t, "4b0febebd439db7ca77153cb254520c3639e1afc3844e616320dab76b5ee18ae", hex.EncodeToString(encrypted))
+	})
+
+	t.Run("gcm", func(t *testing.T) {
+		encryptor, err := NewAesBytesEncryptor(password, hexSalt, ivGen, GCM)
+		require.NoError(t, err)
+
+		encrypted, err := encryptor.Encrypt([]byte("value"))
+		assert.NoError(t, err)
+		assert.Equal(t, "4b0febebd439db7ca77153cb254520c37a560dcc3f2c97bec2db8eac18ba89a7ea57d3063b", hex.EncodeToString(encrypted))
+	})
+
+	t.Run("err ivGen", func(t *testing.T) {
+		encryptor, err := NewAesBytesEncryptor(password, hexSalt, keygentest.ErrBytesKeyGenerator(errors.New("oops"), 16), GCM)
+		require.NoError(t, err)
+
+		_, err = encryptor.Encrypt([]byte("value"))
+		assert.Error(t, err)
+	})
+
+	t.Run("cbc iv length", func(t *testing.T) {
+		encryptor, err := NewAesBytesEncryptor(password, hexSalt, keygen.NewSecureRandomBytesKeyGenerator(12), CBC)
+		require.NoError(t, err)
+
+		_, err = encryptor.Encrypt([]byte("value"))
+		assert.Error(t, err)
+	})
+}
+
+func TestAesBytesEncryptor_Decrypt(t *testing.T) {
+	for _, alg := range []CipherAlgorithm{CBC, GCM} {
+		encryptor, err := NewAesBytesEncryptor(password, hexSalt, keygen.NewSecureRandomBytesKeyGenerator(16), alg)
+		require.NoError(t, err)
+
+		for _, value := range []string{"", "value", "0123456789abcdef", "a longer value spanning multiple blocks"} {
+			encrypted, err := encryptor.Encrypt([]byte(value))
+			require.NoError(t, err)
+
+			decrypted, err := encryptor.Decrypt(encrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, value, string(decrypted))
+		}
+
+		_, err = encryptor.Decrypt([]byte("short"))
+		assert.Error(t, err)
+	}
+
+	t.Run("cbc", func(t *testing.T) {
+		encryptor, err := NewAesBytesEncryptor(password, hexSalt, ivGen, CBC)
+		require.NoError(t, err)
+
+		decrypted, err := encryptor.Decrypt(mustDecodeHex("4b0febebd439db7ca77153cb254520c3639e1afc3844e616320dab76b5ee18ae"))
+		assert.NoError(t, err)
+		assert.Equal(t, "value", string(decrypted))
+
+		_, err = encryptor.Decrypt(mustDecodeHex("4b0febebd439db7ca77153cb254520c3639e1afc"))
+		assert.Error(t, err) // not a multiple of the block size
+
+		_, err = encryptor.Decrypt(mustDecodeHex("4b0febebd439db7ca77153cb254520c3639e1afc3844e616320dab76b5ee18af"))
+		assert.Error(t, err) // invalid padding
+	})
+
+	t.Run("gcm", func(t *testing.T) {
+		encryptor, err := NewAesBytesEncryptor(password, hexSalt, ivGen, GCM)
+		require.NoError(t, err)
+
+		decrypted, err := encryptor.Decrypt(mustDecodeHex("4b0febebd439db7ca77153cb254520c37a560dcc3f2c97bec2db8eac18ba89a7ea57d3063b"))
+		assert.NoError(t, err)
+		assert.Equal(t, "value", string(decrypted))
+
+		_, err = encryptor.Decrypt(mustDecodeHex("4b0febebd439db7ca77153cb254520c37a560dcc3f2c97bec2db8eac18ba89a7ea57d3063c"))
+		assert.Error(t, err) // tag mismatch
+	})
+}
+
+func Test_pkcs5Unpad(t *testing.T) {
+	_, err := pkcs5Unpad(nil, 16)
+	assert.Error(t, err)
+
+	_, err = pkcs5Unpad([]byte{1, 2, 3, 0}, 16)
+	assert.Error(t, err)
+
+	_, err = pkcs5Unpad([]byte{1, 2, 3, 17}, 16)
+	assert.Error(t, err)
+
+	unpadded, err := pkcs5Unpad([]byte{1, 2, 2, 2}, 16)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, unpadded)
+}
@@ -0,0 +1,15 @@
+package encrypt
+
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/encrypt/BytesEncryptor.html
+type BytesEncryptor interface {
+	Encrypt(bytes []byte) ([]byte, error)
+
+	Decrypt(encryptedBytes []byte) ([]byte, error)
+}
+
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/encrypt/TextEncryptor.html
+type TextEncryptor interface {
+	Encrypt(text string) (string, error)
+
+	Decrypt(encryptedText string) (string, error)
+}
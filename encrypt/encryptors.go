@@ -0,0 +1,43 @@
+package encrypt
+
+import "github.com/xuyang2/password-encoder/keygen"
+
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/encrypt/Encryptors.html
+
+// Stronger returns 256-bit AES-GCM encryptor, the key is derived from password and hex-encoded salt.
+// Encryptors.stronger(password, salt)
+func Stronger(password, salt string) (BytesEncryptor, error) {
+	return NewAesBytesEncryptor(password, salt, keygen.NewSecureRandomBytesKeyGenerator(16), GCM)
+}
+
+// Standard returns 256-bit AES-CBC encryptor, the key is derived from password and hex-encoded salt.
+// Encryptors.standard(password, salt)
+func Standard(password, salt string) (BytesEncryptor, error) {
+	return NewAesBytesEncryptor(password, salt, keygen.NewSecureRandomBytesKeyGenerator(16), CBC)
+}
+
+// Delux returns hex-encoding text encryptor using Stronger.
+// Encryptors.delux(password, salt)
+func Delux(password, salt string) (TextEncryptor, error) {
+	encryptor, err := Stronger(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	return NewHexEncodingTextEncryptor(encryptor), nil
+}
+
+// Text returns hex-encoding text encryptor using Standard.
+// Encryptors.text(password, salt)
+func Text(password, salt string) (TextEncryptor, error) {
+	encryptor, err := Standard(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	return NewHexEncodingTextEncryptor(encryptor), nil
+}
+
+// Deprecated. For testing purposes only
+// Encryptors.noOpText()
+func NoOpText() TextEncryptor {
+	return noOpTextEncryptor{}
+}
@@ -0,0 +1,82 @@
+package encrypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptors(t *testing.T) {
+	t.Run("stronger", func(t *testing.T) {
+		encryptor, err := Stronger(password, hexSalt)
+		require.NoError(t, err)
+
+		encrypted, err := encryptor.Encrypt([]byte("value"))
+		assert.NoError(t, err)
+		assert.Len(t, encrypted, 16+len("value")+16) // iv + ciphertext + tag
+
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", string(decrypted))
+	})
+
+	t.Run("standard", func(t *testing.T) {
+		encryptor, err := Standard(password, hexSalt)
+		require.NoError(t, err)
+
+		encrypted, err := encryptor.Encrypt([]byte("value"))
+		assert.NoError(t, err)
+		assert.Len(t, encrypted, 16+16) // iv + one padded block
+
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", string(decrypted))
+	})
+
+	t.Run("delux", func(t *testing.T) {
+		encryptor, err := Delux(password, hexSalt)
+		require.NoError(t, err)
+
+		encrypted, err := encryptor.Encrypt("value")
+		assert.NoError(t, err)
+		assert.NotEqual(t, "value", encrypted)
+
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", decrypted)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		encryptor, err := Text(password, hexSalt)
+		require.NoError(t, err)
+
+		// Encryptors.text("password", "73616c74").encrypt("value")
+		decrypted, err := encryptor.Decrypt("4b0febebd439db7ca77153cb254520c3639e1afc3844e616320dab76b5ee18ae")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", decrypted)
+	})
+
+	t.Run("invalid salt", func(t *testing.T) {
+		_, err := Stronger(password, "salt")
+		assert.Error(t, err)
+		_, err = Standard(password, "salt")
+		assert.Error(t, err)
+		_, err = Delux(password, "salt")
+		assert.Error(t, err)
+		_, err = Text(password, "salt")
+		assert.Error(t, err)
+	})
+
+	t.Run("noop text", func(t *testing.T) {
+		encryptor := NoOpText()
+
+		encrypted, err := encryptor.Encrypt("value")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", encrypted)
+
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", decrypted)
+	})
+}
@@ -0,0 +1,44 @@
+package encrypt
+
+import "encoding/hex"
+
+// HexEncodingTextEncryptor delegates to a BytesEncryptor, the encrypted text is hex-encoded.
+type HexEncodingTextEncryptor struct {
+	encryptor BytesEncryptor
+}
+
+var _ TextEncryptor = (*HexEncodingTextEncryptor)(nil)
+
+func NewHexEncodingTextEncryptor(encryptor BytesEncryptor) *HexEncodingTextEncryptor {
+	return &HexEncodingTextEncryptor{encryptor: encryptor}
+}
+
+func (e *HexEncodingTextEncryptor) Encrypt(text string) (string, error) {
+	encrypted, err := e.encryptor.Encrypt([]byte(text))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(encrypted), nil
+}
+
+func (e *HexEncodingTextEncryptor) Decrypt(encryptedText string) (string, error) {
+	encrypted, err := hex.DecodeString(encryptedText)
+	if err != nil {
+		return "", err
+	}
+	decrypted, err := e.encryptor.Decrypt(encrypted)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
+
+type noOpTextEncryptor struct{}
+
+func (e noOpTextEncryptor) Encrypt(text string) (string, error) {
+	return text, nil
+}
+
+func (e noOpTextEncryptor) Decrypt(encryptedText string) (string, error) {
+	return encryptedText, nil
+}
@@ -0,0 +1,42 @@
+package encrypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuyang2/password-encoder/keygen/keygentest"
+)
+
+func TestHexEncodingTextEncryptor(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		bytesEncryptor, err := NewAesBytesEncryptor(password, hexSalt, ivGen, GCM)
+		require.NoError(t, err)
+		encryptor := NewHexEncodingTextEncryptor(bytesEncryptor)
+
+		encrypted, err := encryptor.Encrypt("value")
+		assert.NoError(t, err)
+		assert.Equal(t, "4b0febebd439db7ca77153cb254520c37a560dcc3f2c97bec2db8eac18ba89a7ea57d3063b", encrypted)
+
+		decrypted, err := encryptor.Decrypt(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", decrypted)
+	})
+
+	t.Run("err", func(t *testing.T) {
+		bytesEncryptor, err := NewAesBytesEncryptor(password, hexSalt, keygentest.ErrBytesKeyGenerator(errors.New("oops"), 16), GCM)
+		require.NoError(t, err)
+		encryptor := NewHexEncodingTextEncryptor(bytesEncryptor)
+
+		_, err = encryptor.Encrypt("value")
+		assert.Error(t, err)
+
+		_, err = encryptor.Decrypt("gg") // invalid hex
+		assert.Error(t, err)
+
+		_, err = encryptor.Decrypt("00") // too short
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,155 @@
+package keygen
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"hash"
+	"io"
+	"sync"
+
+	"github.com/emmansun/gmsm/drbg"
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// DefaultDrbgReseedInterval is the number of keys generated between reseeds.
+const DefaultDrbgReseedInterval = 1 << 16
+
+// drbgSecurityStrength is the security strength in bytes (256 bits),
+// entropy input is drbgSecurityStrength bytes and nonce is half of it.
+const drbgSecurityStrength = 32
+
+type DrbgConfig struct {
+	EntropySource   io.Reader // crypto/rand if nil
+	ReseedInterval  int       // DefaultDrbgReseedInterval if 0
+	Personalization []byte
+}
+
+// DrbgBytesKeyGenerator generates keys from a NIST SP 800-90A / GM/T 0105 DRBG,
+// seeded and periodically reseeded from an entropy source.
+type DrbgBytesKeyGenerator struct {
+	keyLength      int
+	entropySource  io.Reader
+	reseedInterval int
+
+	mu        sync.Mutex
+	drbg      drbg.DRBG
+	generated int // keys generated since the last reseed
+}
+
+var _ BytesKeyGenerator = (*DrbgBytesKeyGenerator)(nil)
+
+// NewHmacDrbgBytesKeyGenerator returns a key generator backed by NIST SP 800-90A HMAC_DRBG, e.g. with sha256.New.
+func NewHmacDrbgBytesKeyGenerator(keyLength int, newHash func() hash.Hash, config DrbgConfig) (*DrbgBytesKeyGenerator, error) {
+	return newDrbgBytesKeyGenerator(keyLength, config, func(entropy, nonce, personalization []byte) (drbg.DRBG, error) {
+		return drbg.NewNISTHmacDrbg(newHash, drbg.SECURITY_LEVEL_ONE, entropy, nonce, personalization)
+	})
+}
+
+// NewCtrDrbgBytesKeyGenerator returns a key generator backed by NIST SP 800-90A CTR_DRBG with AES-256.
+func NewCtrDrbgBytesKeyGenerator(keyLength int, config DrbgConfig) (*DrbgBytesKeyGenerator, error) {
+	return newDrbgBytesKeyGenerator(keyLength, config, func(entropy, nonce, personalization []byte) (drbg.DRBG, error) {
+		return drbg.NewNISTCtrDrbg(aes.NewCipher, 32, drbg.SECURITY_LEVEL_ONE, entropy, nonce, personalization)
+	})
+}
+
+// NewSm3DrbgBytesKeyGenerator returns a key generator backed by GM/T 0105-2021 Hash_DRBG with SM3.
+func NewSm3DrbgBytesKeyGenerator(keyLength int, config DrbgConfig) (*DrbgBytesKeyGenerator, error) {
+	return newDrbgBytesKeyGenerator(keyLength, config, func(entropy, nonce, personalization []byte) (drbg.DRBG, error) {
+		return drbg.NewHashDrbg(sm3.New, drbg.SECURITY_LEVEL_ONE, true, entropy, nonce, personalization)
+	})
+}
+
+func newDrbgBytesKeyGenerator(keyLength int, config DrbgConfig, instantiate func(entropy, nonce, personalization []byte) (drbg.DRBG, error)) (*DrbgBytesKeyGenerator, error) {
+	g := &DrbgBytesKeyGenerator{
+		keyLength:      keyLength,
+		entropySource:  config.EntropySource,
+		reseedInterval: config.ReseedInterval,
+	}
+	if g.entropySource == nil {
+		g.entropySource = rand.Reader
+	}
+	if g.reseedInterval <= 0 {
+		g.reseedInterval = DefaultDrbgReseedInterval
+	}
+
+	entropy, err := g.entropy(drbgSecurityStrength)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := g.entropy(drbgSecurityStrength / 2)
+	if err != nil {
+		return nil, err
+	}
+	g.drbg, err = instantiate(entropy, nonce, config.Personalization)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *DrbgBytesKeyGenerator) KeyLength() int {
+	return g.keyLength
+}
+
+func (g *DrbgBytesKeyGenerator) GenerateKey() ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.generated >= g.reseedInterval {
+		if err := g.reseed(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	key := make([]byte, g.keyLength)
+	for b := key; len(b) > 0; {
+		n := len(b)
+		if n > g.drbg.MaxBytesPerRequest() {
+			n = g.drbg.MaxBytesPerRequest()
+		}
+
+		err := g.drbg.Generate(b[:n], nil)
+		if err == drbg.ErrReseedRequired {
+			err = g.reseed(nil)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		b = b[n:]
+	}
+	g.generated++
+
+	return key, nil
+}
+
+// Reseed reseeds the DRBG from the entropy source, with optional additional input.
+func (g *DrbgBytesKeyGenerator) Reseed(additional []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.reseed(additional)
+}
+
+func (g *DrbgBytesKeyGenerator) reseed(additional []byte) error {
+	entropy, err := g.entropy(drbgSecurityStrength)
+	if err != nil {
+		return err
+	}
+	if err := g.drbg.Reseed(entropy, additional); err != nil {
+		return err
+	}
+	g.generated = 0
+	return nil
+}
+
+func (g *DrbgBytesKeyGenerator) entropy(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(g.entropySource, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
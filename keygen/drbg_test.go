@@ -0,0 +1,172 @@
+package keygen
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// entropySource returns entropyInput + nonce + entropyInputReseed, as read by DrbgBytesKeyGenerator
+func entropySource(hexStrings ...string) io.Reader {
+	var bb bytes.Buffer
+	for _, s := range hexStrings {
+		bb.Write(mustDecodeHex(s))
+	}
+	return &bb
+}
+
+// Known-answer tests: instantiate, reseed, generate, generate
+func TestDrbgBytesKeyGenerator_KnownAnswer(t *testing.T) {
+	tests := []struct {
+		name               string
+		newGenerator       func(keyLength int, config DrbgConfig) (*DrbgBytesKeyGenerator, error)
+		entropyInput       string
+		nonce              string
+		entropyInputReseed string
+		returnedBits       string
+	}{
+		{
+			// NIST CAVP HMAC_DRBG.rsp [SHA-256] [PredictionResistance = False] COUNT = 0
+			name: "HMAC_DRBG SHA-256",
+			newGenerator: func(keyLength int, config DrbgConfig) (*DrbgBytesKeyGenerator, error) {
+				return NewHmacDrbgBytesKeyGenerator(keyLength, sha256.New, config)
+			},
+			entropyInput:       "06032cd5eed33f39265f49ecb142c511da9aff2af71203bffaf34a9ca5bd9c0d",
+			nonce:              "0e66f71edc43e42a45ad3c6fc6cdc4df",
+			entropyInputReseed: "01920a4e669ed3a85ae8a33b35a74ad7fb2a6bb4cf395ce00334a9c9a5a5d552",
+			returnedBits:       "76fc79fe9b50beccc991a11b5635783a83536add03c157fb30645e611c2898bb2b1bc215000209208cd506cb28da2a51bdb03826aaf2bd2335d576d519160842e7158ad0949d1a9ec3e66ea1b1a064b005de914eac2e9d4f2d72a8616a80225422918250ff66a41bd2f864a6a38cc5b6499dc43f7f2bd09e1e0f8f5885935124",
+		},
+		{
+			// NIST CAVP CTR_DRBG.rsp [AES-256 use df] [PredictionResistance = False] COUNT = 0
+			name:               "CTR_DRBG AES-256",
+			newGenerator:       NewCtrDrbgBytesKeyGenerator,
+			entropyInput:       "2d4c9f46b981c6a0b2b5d8c69391e569ff13851437ebc0fc00d616340252fed5",
+			nonce:              "0bf814b411f65ec4866be1abb59d3c32",
+			entropyInputReseed: "93500fae4fa32b86033b7a7bac9d37e710dcc67ca266bc8607d665937766d207",
+			returnedBits:       "322dd28670e75c0ea638f3cb68d6a9d6e50ddfd052b772a7b1d78263a7b8978b6740c2b65a9550c3a76325866fa97e16d74006bc96f26249b9f0a90d076f08e5",
+		},
+		{
+			// GM/T 0105-2021 Hash_DRBG SM3
+			name:               "Hash_DRBG SM3",
+			newGenerator:       NewSm3DrbgBytesKeyGenerator,
+			entropyInput:       "63363377e41e86468deb0ab4a8ed683f6a134e47e014c700454e81e95358a569",
+			nonce:              "808aa38f2a72a62359915a9f8a04ca68",
+			entropyInputReseed: "e62b8a8ee8f141b6980566e3bfe3c04903dad4ac2cdf9f2280010a6739bc83d3",
+			returnedBits:       "00d98d35a2fab8df23e9e1fb9aad143d62c0759eb79e15c37e8f2bc5064e68da",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			returnedBits := mustDecodeHex(tt.returnedBits)
+			gen, err := tt.newGenerator(len(returnedBits), DrbgConfig{
+				EntropySource: entropySource(tt.entropyInput, tt.nonce, tt.entropyInputReseed),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, len(returnedBits), gen.KeyLength())
+
+			require.NoError(t, gen.Reseed(nil))
+
+			_, err = gen.GenerateKey()
+			require.NoError(t, err)
+			key, err := gen.GenerateKey()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.returnedBits, hex.EncodeToString(key))
+		})
+	}
+}
+
+func TestDrbgBytesKeyGenerator_GenerateKey(t *testing.T) {
+	t.Run("crypto/rand", func(t *testing.T) {
+		for _, newGenerator := range []func() (*DrbgBytesKeyGenerator, error){
+			func() (*DrbgBytesKeyGenerator, error) {
+				return NewHmacDrbgBytesKeyGenerator(16, sha256.New, DrbgConfig{})
+			},
+			func() (*DrbgBytesKeyGenerator, error) { return NewCtrDrbgBytesKeyGenerator(16, DrbgConfig{}) },
+			func() (*DrbgBytesKeyGenerator, error) { return NewSm3DrbgBytesKeyGenerator(16, DrbgConfig{}) },
+		} {
+			gen, err := newGenerator()
+			require.NoError(t, err)
+
+			key1, err := gen.GenerateKey()
+			assert.NoError(t, err)
+			key2, err := gen.GenerateKey()
+			assert.NoError(t, err)
+			assert.Len(t, key1, 16)
+			assert.NotEqual(t, key1, key2)
+		}
+	})
+
+	t.Run("long key", func(t *testing.T) {
+		gen, err := NewSm3DrbgBytesKeyGenerator(5000, DrbgConfig{})
+		require.NoError(t, err)
+
+		key, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Len(t, key, 5000)
+	})
+
+	t.Run("personalization", func(t *testing.T) {
+		seed := make([]byte, 3*drbgSecurityStrength)
+		_, err := rand.Read(seed)
+		require.NoError(t, err)
+
+		gen1, err := NewHmacDrbgBytesKeyGenerator(16, sha256.New, DrbgConfig{EntropySource: bytes.NewReader(seed)})
+		require.NoError(t, err)
+		gen2, err := NewHmacDrbgBytesKeyGenerator(16, sha256.New, DrbgConfig{EntropySource: bytes.NewReader(seed)})
+		require.NoError(t, err)
+		gen3, err := NewHmacDrbgBytesKeyGenerator(16, sha256.New, DrbgConfig{EntropySource: bytes.NewReader(seed), Personalization: []byte("password-encoder")})
+		require.NoError(t, err)
+
+		key1, err := gen1.GenerateKey()
+		require.NoError(t, err)
+		key2, err := gen2.GenerateKey()
+		require.NoError(t, err)
+		key3, err := gen3.GenerateKey()
+		require.NoError(t, err)
+
+		assert.Equal(t, key1, key2)
+		assert.NotEqual(t, key1, key3)
+	})
+
+	t.Run("reseed interval", func(t *testing.T) {
+		// instantiate + 2 reseeds
+		seed := make([]byte, drbgSecurityStrength+drbgSecurityStrength/2+2*drbgSecurityStrength)
+		gen, err := NewCtrDrbgBytesKeyGenerator(16, DrbgConfig{EntropySource: bytes.NewReader(seed), ReseedInterval: 2})
+		require.NoError(t, err)
+
+		for i := 0; i < 6; i++ {
+			_, err := gen.GenerateKey()
+			assert.NoError(t, err)
+		}
+		_, err = gen.GenerateKey()
+		assert.Error(t, err) // entropy source exhausted
+	})
+
+	t.Run("err entropy", func(t *testing.T) {
+		_, err := NewCtrDrbgBytesKeyGenerator(16, DrbgConfig{EntropySource: ErrReader(errors.New("WTF"))})
+		assert.Error(t, err)
+
+		_, err = NewCtrDrbgBytesKeyGenerator(16, DrbgConfig{EntropySource: bytes.NewReader(make([]byte, drbgSecurityStrength))})
+		assert.Error(t, err) // no nonce
+
+		gen, err := NewCtrDrbgBytesKeyGenerator(16, DrbgConfig{EntropySource: bytes.NewReader(make([]byte, drbgSecurityStrength+drbgSecurityStrength/2))})
+		require.NoError(t, err)
+		assert.Error(t, gen.Reseed(nil))
+	})
+}
@@ -0,0 +1,78 @@
+package keygen
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+)
+
+// ErrHealthCheck is returned once the random source looks stuck, and on every call after that.
+var ErrHealthCheck = errors.New("keygen: random source failed health check")
+
+// repetitionCountCutoff is the NIST SP 800-90B 4.4.1 Repetition Count Test cutoff
+// C = 1 + ceil(-log2(alpha) / H), assuming H = 8 bits of entropy per byte and alpha = 2^-40.
+const repetitionCountCutoff = 6
+
+// minComparedKeyLength is the minimum key length of the FIPS 140-2 continuous test,
+// shorter keys repeat legitimately.
+const minComparedKeyLength = 8
+
+// HealthCheckedBytesKeyGenerator fails generation when the wrapped generator looks stuck:
+// a byte repeated repetitionCountCutoff times in a row (NIST SP 800-90B Repetition Count Test),
+// or the same key generated twice in a row (FIPS 140-2 continuous random number generator test).
+type HealthCheckedBytesKeyGenerator struct {
+	gen BytesKeyGenerator
+
+	mu       sync.Mutex
+	failed   bool
+	lastKey  []byte
+	lastByte byte
+	count    int // repetitions of lastByte
+}
+
+var _ BytesKeyGenerator = (*HealthCheckedBytesKeyGenerator)(nil)
+
+func NewHealthCheckedBytesKeyGenerator(gen BytesKeyGenerator) *HealthCheckedBytesKeyGenerator {
+	return &HealthCheckedBytesKeyGenerator{gen: gen}
+}
+
+func (g *HealthCheckedBytesKeyGenerator) KeyLength() int {
+	return g.gen.KeyLength()
+}
+
+func (g *HealthCheckedBytesKeyGenerator) GenerateKey() ([]byte, error) {
+	key, err := g.gen.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.failed {
+		return nil, ErrHealthCheck
+	}
+
+	for _, b := range key {
+		if g.count > 0 && b == g.lastByte {
+			g.count++
+		} else {
+			g.lastByte = b
+			g.count = 1
+		}
+		if g.count >= repetitionCountCutoff {
+			g.failed = true
+			return nil, ErrHealthCheck
+		}
+	}
+
+	if len(key) >= minComparedKeyLength {
+		if bytes.Equal(key, g.lastKey) {
+			g.failed = true
+			return nil, ErrHealthCheck
+		}
+		g.lastKey = append(g.lastKey[:0], key...)
+	}
+
+	return key, nil
+}
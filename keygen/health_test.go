@@ -0,0 +1,68 @@
+package keygen
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckedBytesKeyGenerator_GenerateKey(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		gen := NewHealthCheckedBytesKeyGenerator(NewSecureRandomBytesKeyGenerator(16))
+		assert.Equal(t, 16, gen.KeyLength())
+
+		for i := 0; i < 1000; i++ {
+			key, err := gen.GenerateKey()
+			assert.NoError(t, err)
+			assert.Len(t, key, 16)
+		}
+	})
+
+	t.Run("stuck byte", func(t *testing.T) {
+		gen := NewHealthCheckedBytesKeyGenerator(NewSecureRandomBytesKeyGeneratorWithReader(8, bytes.NewReader(make([]byte, 64))))
+		_, err := gen.GenerateKey()
+		assert.ErrorIs(t, err, ErrHealthCheck)
+	})
+
+	t.Run("stuck byte across keys", func(t *testing.T) {
+		// 1 2 0 0 | 0 0 0 0
+		gen := NewHealthCheckedBytesKeyGenerator(NewSecureRandomBytesKeyGeneratorWithReader(4, bytes.NewReader([]byte{1, 2, 0, 0, 0, 0, 0, 0})))
+		_, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		_, err = gen.GenerateKey()
+		assert.ErrorIs(t, err, ErrHealthCheck)
+	})
+
+	t.Run("repeated key", func(t *testing.T) {
+		gen := NewHealthCheckedBytesKeyGenerator(NewSharedKeyGenerator([]byte{1, 2, 3, 4, 5, 6, 7, 8}))
+		_, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		_, err = gen.GenerateKey()
+		assert.ErrorIs(t, err, ErrHealthCheck)
+	})
+
+	t.Run("short keys may repeat", func(t *testing.T) {
+		gen := NewHealthCheckedBytesKeyGenerator(NewSharedKeyGenerator([]byte{1, 2}))
+		for i := 0; i < 3; i++ {
+			_, err := gen.GenerateKey()
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("failure is latched", func(t *testing.T) {
+		gen := NewHealthCheckedBytesKeyGenerator(NewSecureRandomBytesKeyGeneratorWithReader(8, bytes.NewReader(append(make([]byte, 8), 1, 2, 3, 4, 5, 6, 7, 8))))
+		_, err := gen.GenerateKey()
+		assert.ErrorIs(t, err, ErrHealthCheck)
+		_, err = gen.GenerateKey()
+		assert.ErrorIs(t, err, ErrHealthCheck)
+	})
+
+	t.Run("err", func(t *testing.T) {
+		gen := NewHealthCheckedBytesKeyGenerator(NewSecureRandomBytesKeyGeneratorWithReader(8, ErrReader(errors.New("WTF"))))
+		_, err := gen.GenerateKey()
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrHealthCheck)
+	})
+}
@@ -5,8 +5,9 @@ import (
 	"io"
 )
 
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/keygen/BytesKeyGenerator.html
 type BytesKeyGenerator interface {
-	// KeyLength() int
+	KeyLength() int
 
 	GenerateKey() ([]byte, error)
 }
@@ -17,9 +18,14 @@ type SecureRandomBytesKeyGenerator struct {
 }
 
 func NewSecureRandomBytesKeyGenerator(keyLength int) *SecureRandomBytesKeyGenerator {
+	return NewSecureRandomBytesKeyGeneratorWithReader(keyLength, rand.Reader)
+}
+
+// NewSecureRandomBytesKeyGeneratorWithReader reads keys from rand instead of crypto/rand.
+func NewSecureRandomBytesKeyGeneratorWithReader(keyLength int, rand io.Reader) *SecureRandomBytesKeyGenerator {
 	return &SecureRandomBytesKeyGenerator{
 		keyLength: keyLength,
-		rand:      rand.Reader,
+		rand:      rand,
 	}
 }
 
@@ -30,7 +36,8 @@ func (g *SecureRandomBytesKeyGenerator) KeyLength() int {
 func (g *SecureRandomBytesKeyGenerator) GenerateKey() ([]byte, error) {
 	b := make([]byte, g.keyLength)
 
-	_, err := g.rand.Read(b)
+	// a single Read may return fewer bytes, e.g. from a wrapped reader
+	_, err := io.ReadFull(g.rand, b)
 
 	if err != nil {
 		return nil, err
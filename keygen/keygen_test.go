@@ -1,9 +1,11 @@
 package keygen
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -22,6 +24,19 @@ func TestSecureRandomBytesKeyGenerator_GenerateKey(t *testing.T) {
 		_, err := gen.GenerateKey()
 		assert.Error(t, err)
 	})
+
+	t.Run("partial reads", func(t *testing.T) {
+		gen := NewSecureRandomBytesKeyGeneratorWithReader(8, iotest.OneByteReader(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8})))
+		key, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, key)
+	})
+
+	t.Run("short read", func(t *testing.T) {
+		gen := NewSecureRandomBytesKeyGeneratorWithReader(8, bytes.NewReader([]byte{1, 2, 3}))
+		_, err := gen.GenerateKey()
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
 }
 
 func TestSecureRandomBytesKeyGenerator_KeyLength(t *testing.T) {
@@ -0,0 +1,60 @@
+package keygen
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// DefaultKeyLength is the key length of SecureRandom() and HexString(), as in Spring.
+const DefaultKeyLength = 8
+
+// KeyGenerators creates key generators reading from rand.
+//
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/keygen/KeyGenerators.html
+type KeyGenerators struct {
+	rand io.Reader
+}
+
+var defaultKeyGenerators = NewKeyGenerators(rand.Reader)
+
+// NewKeyGenerators returns a factory reading from rand, e.g. a deterministic reader in tests.
+func NewKeyGenerators(rand io.Reader) KeyGenerators {
+	return KeyGenerators{rand: rand}
+}
+
+// SecureRandom returns a BytesKeyGenerator generating keyLength bytes keys.
+// KeyGenerators.secureRandom(keyLength)
+func (f KeyGenerators) SecureRandom(keyLength int) *SecureRandomBytesKeyGenerator {
+	return NewSecureRandomBytesKeyGeneratorWithReader(keyLength, f.rand)
+}
+
+// Shared returns a BytesKeyGenerator generating a keyLength bytes key once, then returning it on every call.
+// KeyGenerators.shared(keyLength)
+func (f KeyGenerators) Shared(keyLength int) (*SharedKeyGenerator, error) {
+	key, err := f.SecureRandom(keyLength).GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewSharedKeyGenerator(key), nil
+}
+
+// HexString returns a StringKeyGenerator generating hex-encoded DefaultKeyLength bytes keys.
+// KeyGenerators.string()
+func (f KeyGenerators) HexString() StringKeyGenerator {
+	return NewHexEncodingStringKeyGenerator(f.SecureRandom(DefaultKeyLength))
+}
+
+// SecureRandom reads from crypto/rand, see KeyGenerators.SecureRandom
+func SecureRandom(keyLength int) *SecureRandomBytesKeyGenerator {
+	return defaultKeyGenerators.SecureRandom(keyLength)
+}
+
+// Shared reads from crypto/rand, see KeyGenerators.Shared
+func Shared(keyLength int) (*SharedKeyGenerator, error) {
+	return defaultKeyGenerators.Shared(keyLength)
+}
+
+// HexString reads from crypto/rand, see KeyGenerators.HexString
+func HexString() StringKeyGenerator {
+	return defaultKeyGenerators.HexString()
+}
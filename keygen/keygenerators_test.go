@@ -0,0 +1,75 @@
+package keygen
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyGenerators(t *testing.T) {
+	t.Run("SecureRandom", func(t *testing.T) {
+		gen := NewKeyGenerators(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6})).SecureRandom(3)
+		assert.Equal(t, 3, gen.KeyLength())
+
+		key1, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3}, key1)
+
+		key2, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{4, 5, 6}, key2)
+	})
+
+	t.Run("Shared", func(t *testing.T) {
+		gen, err := NewKeyGenerators(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6})).Shared(3)
+		require.NoError(t, err)
+		assert.Equal(t, 3, gen.KeyLength())
+
+		for i := 0; i < 2; i++ {
+			key, err := gen.GenerateKey()
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{1, 2, 3}, key)
+		}
+	})
+
+	t.Run("Shared err", func(t *testing.T) {
+		_, err := NewKeyGenerators(ErrReader(errors.New("WTF"))).Shared(3)
+		assert.Error(t, err)
+	})
+
+	t.Run("HexString", func(t *testing.T) {
+		gen := NewKeyGenerators(bytes.NewReader([]byte{1, 2, 3, 4, 5, 6, 7, 8})).HexString()
+		key, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Equal(t, "0102030405060708", key)
+	})
+}
+
+func TestDefaultKeyGenerators(t *testing.T) {
+	t.Run("SecureRandom", func(t *testing.T) {
+		key, err := SecureRandom(16).GenerateKey()
+		assert.NoError(t, err)
+		assert.Len(t, key, 16)
+	})
+
+	t.Run("Shared", func(t *testing.T) {
+		gen, err := Shared(16)
+		require.NoError(t, err)
+
+		key1, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		key2, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Len(t, key1, 16)
+		assert.Equal(t, key1, key2)
+	})
+
+	t.Run("HexString", func(t *testing.T) {
+		key, err := HexString().GenerateKey()
+		assert.NoError(t, err)
+		assert.Len(t, key, 2*DefaultKeyLength)
+	})
+}
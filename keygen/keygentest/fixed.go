@@ -0,0 +1,76 @@
+package keygentest
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"golang.org/x/crypto/chacha20"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// FixedBytesKeyGenerator returns key on every call.
+func FixedBytesKeyGenerator(key []byte) keygen.BytesKeyGenerator {
+	return keygen.NewSharedKeyGenerator(key)
+}
+
+type sequenceBytesKeyGenerator struct {
+	mu        sync.Mutex
+	counter   uint64
+	keyLength int
+}
+
+func (g *sequenceBytesKeyGenerator) KeyLength() int {
+	return g.keyLength
+}
+
+func (g *sequenceBytesKeyGenerator) GenerateKey() ([]byte, error) {
+	g.mu.Lock()
+	counter := g.counter
+	g.counter++
+	g.mu.Unlock()
+
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], counter)
+
+	key := make([]byte, g.keyLength)
+	if g.keyLength >= len(b) {
+		copy(key[g.keyLength-len(b):], b[:])
+	} else {
+		copy(key, b[len(b)-g.keyLength:])
+	}
+	return key, nil
+}
+
+// SequenceBytesKeyGenerator returns a big-endian counter 0, 1, 2, ... as keyLength bytes keys.
+func SequenceBytesKeyGenerator(keyLength int) keygen.BytesKeyGenerator {
+	return &sequenceBytesKeyGenerator{keyLength: keyLength}
+}
+
+type keyStreamReader struct {
+	mu     sync.Mutex
+	cipher *chacha20.Cipher
+}
+
+func (r *keyStreamReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range p {
+		p[i] = 0
+	}
+	r.cipher.XORKeyStream(p, p)
+	return len(p), nil
+}
+
+// SeededBytesKeyGenerator returns keys read from the ChaCha20 keystream keyed by seed,
+// the same seed always generates the same sequence of keys.
+func SeededBytesKeyGenerator(seed uint64, keyLength int) keygen.BytesKeyGenerator {
+	key := make([]byte, chacha20.KeySize)
+	binary.BigEndian.PutUint64(key, seed)
+	c, err := chacha20.NewUnauthenticatedCipher(key, make([]byte, chacha20.NonceSize))
+	if err != nil {
+		panic(err)
+	}
+	return keygen.NewKeyGenerators(&keyStreamReader{cipher: c}).SecureRandom(keyLength)
+}
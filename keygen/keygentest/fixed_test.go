@@ -0,0 +1,63 @@
+package keygentest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedBytesKeyGenerator(t *testing.T) {
+	gen := FixedBytesKeyGenerator([]byte{1, 2, 3})
+	assert.Equal(t, 3, gen.KeyLength())
+
+	for i := 0; i < 2; i++ {
+		key, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3}, key)
+	}
+}
+
+func TestSequenceBytesKeyGenerator(t *testing.T) {
+	t.Run("long", func(t *testing.T) {
+		gen := SequenceBytesKeyGenerator(10)
+		assert.Equal(t, 10, gen.KeyLength())
+
+		for i := 0; i < 3; i++ {
+			key, err := gen.GenerateKey()
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, byte(i)}, key)
+		}
+	})
+
+	t.Run("short", func(t *testing.T) {
+		gen := SequenceBytesKeyGenerator(2)
+		for i := 0; i < 258; i++ {
+			key, err := gen.GenerateKey()
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{byte(i >> 8), byte(i)}, key)
+		}
+	})
+}
+
+func TestSeededBytesKeyGenerator(t *testing.T) {
+	gen1 := SeededBytesKeyGenerator(42, 16)
+	gen2 := SeededBytesKeyGenerator(42, 16)
+	gen3 := SeededBytesKeyGenerator(43, 16)
+	assert.Equal(t, 16, gen1.KeyLength())
+
+	key1, err := gen1.GenerateKey()
+	assert.NoError(t, err)
+	assert.Len(t, key1, 16)
+
+	key2, err := gen2.GenerateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, key1, key2)
+
+	key3, err := gen3.GenerateKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key1, key3)
+
+	next, err := gen1.GenerateKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key1, next)
+}
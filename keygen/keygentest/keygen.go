@@ -1,6 +1,10 @@
 package keygentest
 
-import "github.com/xuyang2/password-encoder/keygen"
+import (
+	"sync"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
 
 type errBytesKeyGenerator struct {
 	err       error
@@ -21,3 +25,58 @@ func ErrBytesKeyGenerator(err error, keyLength int) keygen.BytesKeyGenerator {
 		keyLength: keyLength,
 	}
 }
+
+type shortBytesKeyGenerator struct {
+	gen keygen.BytesKeyGenerator
+	n   int
+}
+
+func (g *shortBytesKeyGenerator) KeyLength() int {
+	return g.gen.KeyLength()
+}
+
+func (g *shortBytesKeyGenerator) GenerateKey() ([]byte, error) {
+	key, err := g.gen.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) > g.n {
+		key = key[:g.n]
+	}
+	return key, nil
+}
+
+// ShortBytesKeyGenerator simulates a short read: keys are truncated to n bytes
+// while KeyLength still reports the length of gen.
+func ShortBytesKeyGenerator(gen keygen.BytesKeyGenerator, n int) keygen.BytesKeyGenerator {
+	return &shortBytesKeyGenerator{gen: gen, n: n}
+}
+
+type failAfterBytesKeyGenerator struct {
+	gen keygen.BytesKeyGenerator
+	err error
+
+	mu sync.Mutex
+	n  int
+}
+
+func (g *failAfterBytesKeyGenerator) KeyLength() int {
+	return g.gen.KeyLength()
+}
+
+func (g *failAfterBytesKeyGenerator) GenerateKey() ([]byte, error) {
+	g.mu.Lock()
+	if g.n <= 0 {
+		g.mu.Unlock()
+		return nil, g.err
+	}
+	g.n--
+	g.mu.Unlock()
+
+	return g.gen.GenerateKey()
+}
+
+// FailAfterBytesKeyGenerator delegates the first n calls to gen, then returns err.
+func FailAfterBytesKeyGenerator(gen keygen.BytesKeyGenerator, n int, err error) keygen.BytesKeyGenerator {
+	return &failAfterBytesKeyGenerator{gen: gen, err: err, n: n}
+}
@@ -22,3 +22,33 @@ func TestErrBytesKeyGenerator(t *testing.T) {
 		assert.Equal(t, 8, gen.KeyLength())
 	}
 }
+
+func TestShortBytesKeyGenerator(t *testing.T) {
+	gen := ShortBytesKeyGenerator(FixedBytesKeyGenerator([]byte{1, 2, 3, 4}), 2)
+	assert.Equal(t, 4, gen.KeyLength())
+
+	key, err := gen.GenerateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, key)
+
+	gen = ShortBytesKeyGenerator(ErrBytesKeyGenerator(errors.New("WTF"), 4), 2)
+	_, err = gen.GenerateKey()
+	assert.Error(t, err)
+}
+
+func TestFailAfterBytesKeyGenerator(t *testing.T) {
+	wtf := errors.New("WTF")
+	gen := FailAfterBytesKeyGenerator(FixedBytesKeyGenerator([]byte{1, 2, 3, 4}), 2, wtf)
+	assert.Equal(t, 4, gen.KeyLength())
+
+	for i := 0; i < 2; i++ {
+		key, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3, 4}, key)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := gen.GenerateKey()
+		assert.ErrorIs(t, err, wtf)
+	}
+}
@@ -0,0 +1,59 @@
+package keygentest
+
+import (
+	"sync"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// RecordingBytesKeyGenerator captures every key generated by the wrapped generator.
+type RecordingBytesKeyGenerator struct {
+	gen keygen.BytesKeyGenerator
+
+	mu   sync.Mutex
+	keys [][]byte
+}
+
+func NewRecordingBytesKeyGenerator(gen keygen.BytesKeyGenerator) *RecordingBytesKeyGenerator {
+	return &RecordingBytesKeyGenerator{gen: gen}
+}
+
+func (g *RecordingBytesKeyGenerator) KeyLength() int {
+	return g.gen.KeyLength()
+}
+
+func (g *RecordingBytesKeyGenerator) GenerateKey() ([]byte, error) {
+	key, err := g.gen.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	g.keys = append(g.keys, append([]byte(nil), key...))
+	g.mu.Unlock()
+
+	return key, nil
+}
+
+// Keys returns copies of the generated keys, in order.
+func (g *RecordingBytesKeyGenerator) Keys() [][]byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := make([][]byte, len(g.keys))
+	for i, key := range g.keys {
+		keys[i] = append([]byte(nil), key...)
+	}
+	return keys
+}
+
+// LastKey returns a copy of the last generated key, nil if none.
+func (g *RecordingBytesKeyGenerator) LastKey() []byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.keys) == 0 {
+		return nil
+	}
+	return append([]byte(nil), g.keys[len(g.keys)-1]...)
+}
@@ -0,0 +1,32 @@
+package keygentest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingBytesKeyGenerator(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		gen := NewRecordingBytesKeyGenerator(SequenceBytesKeyGenerator(2))
+		assert.Equal(t, 2, gen.KeyLength())
+		assert.Nil(t, gen.LastKey())
+
+		for i := 0; i < 3; i++ {
+			key, err := gen.GenerateKey()
+			assert.NoError(t, err)
+			key[0] = 0xff // callers may modify generated keys
+		}
+
+		assert.Equal(t, [][]byte{{0, 0}, {0, 1}, {0, 2}}, gen.Keys())
+		assert.Equal(t, []byte{0, 2}, gen.LastKey())
+	})
+
+	t.Run("err", func(t *testing.T) {
+		gen := NewRecordingBytesKeyGenerator(ErrBytesKeyGenerator(errors.New("WTF"), 2))
+		_, err := gen.GenerateKey()
+		assert.Error(t, err)
+		assert.Empty(t, gen.Keys())
+	})
+}
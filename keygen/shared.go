@@ -0,0 +1,20 @@
+package keygen
+
+// SharedKeyGenerator returns the same key on every call.
+type SharedKeyGenerator struct {
+	sharedKey []byte
+}
+
+var _ BytesKeyGenerator = (*SharedKeyGenerator)(nil)
+
+func NewSharedKeyGenerator(sharedKey []byte) *SharedKeyGenerator {
+	return &SharedKeyGenerator{sharedKey: append([]byte(nil), sharedKey...)}
+}
+
+func (g *SharedKeyGenerator) KeyLength() int {
+	return len(g.sharedKey)
+}
+
+func (g *SharedKeyGenerator) GenerateKey() ([]byte, error) {
+	return append([]byte(nil), g.sharedKey...), nil
+}
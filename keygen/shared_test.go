@@ -0,0 +1,27 @@
+package keygen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedKeyGenerator_GenerateKey(t *testing.T) {
+	sharedKey := []byte{1, 2, 3}
+	gen := NewSharedKeyGenerator(sharedKey)
+	sharedKey[0] = 0
+
+	key1, err := gen.GenerateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, key1)
+
+	key1[0] = 0
+	key2, err := gen.GenerateKey()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, key2)
+}
+
+func TestSharedKeyGenerator_KeyLength(t *testing.T) {
+	gen := NewSharedKeyGenerator([]byte{1, 2, 3})
+	assert.Equal(t, 3, gen.KeyLength())
+}
@@ -0,0 +1,27 @@
+package keygen
+
+import "encoding/hex"
+
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/keygen/StringKeyGenerator.html
+type StringKeyGenerator interface {
+	GenerateKey() (string, error)
+}
+
+// HexEncodingStringKeyGenerator hex-encodes the keys generated by a BytesKeyGenerator.
+type HexEncodingStringKeyGenerator struct {
+	keyGenerator BytesKeyGenerator
+}
+
+var _ StringKeyGenerator = (*HexEncodingStringKeyGenerator)(nil)
+
+func NewHexEncodingStringKeyGenerator(keyGenerator BytesKeyGenerator) *HexEncodingStringKeyGenerator {
+	return &HexEncodingStringKeyGenerator{keyGenerator: keyGenerator}
+}
+
+func (g *HexEncodingStringKeyGenerator) GenerateKey() (string, error) {
+	key, err := g.keyGenerator.GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
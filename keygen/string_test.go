@@ -0,0 +1,24 @@
+package keygen
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHexEncodingStringKeyGenerator_GenerateKey(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		gen := NewHexEncodingStringKeyGenerator(NewKeyGenerators(bytes.NewReader([]byte{0x01, 0x23, 0xab, 0xcd})).SecureRandom(4))
+		key, err := gen.GenerateKey()
+		assert.NoError(t, err)
+		assert.Equal(t, "0123abcd", key)
+	})
+
+	t.Run("err", func(t *testing.T) {
+		gen := NewHexEncodingStringKeyGenerator(NewKeyGenerators(ErrReader(errors.New("WTF"))).SecureRandom(4))
+		_, err := gen.GenerateKey()
+		assert.Error(t, err)
+	})
+}
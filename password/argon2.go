@@ -0,0 +1,202 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// Argon2PasswordEncoder is Argon2id, compatible with Spring's Argon2PasswordEncoder.
+//
+// The encoded password is in PHC string format:
+// "$argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<base64 salt>$<base64 hash>".
+// Matches also accepts argon2i.
+//
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/crypto/argon2/Argon2PasswordEncoder.html
+type Argon2PasswordEncoder struct {
+	saltGen     keygen.BytesKeyGenerator
+	hashLength  int
+	parallelism int
+	memory      int // KiB
+	iterations  int
+}
+
+var _ ContextPasswordEncoder = (*Argon2PasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*Argon2PasswordEncoder)(nil)
+
+// Argon2PasswordEncoder.defaultsForSpringSecurity_v5_8()
+func DefaultArgon2PasswordEncoder() *Argon2PasswordEncoder {
+	saltLen := 16
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(saltLen)
+	return NewArgon2PasswordEncoder(saltGen, 32, 1, 1<<14, 2)
+}
+
+// panics if saltGen generates keys shorter than MinSaltLength, iterations < 1, parallelism is not in [1, 255]
+// or memory (KiB) < 8*parallelism
+func NewArgon2PasswordEncoder(saltGen keygen.BytesKeyGenerator, hashLength, parallelism, memory, iterations int) *Argon2PasswordEncoder {
+	mustValidSaltGen(saltGen)
+	if iterations < 1 {
+		panic(fmt.Errorf("iterations %d must be positive", iterations))
+	}
+	if parallelism < 1 || parallelism > 255 {
+		panic(fmt.Errorf("parallelism %d must be in [1, 255]", parallelism))
+	}
+	if memory < 8*parallelism {
+		panic(fmt.Errorf("memory %d KiB must be at least 8*parallelism", memory))
+	}
+	return &Argon2PasswordEncoder{
+		saltGen:     saltGen,
+		hashLength:  hashLength,
+		parallelism: parallelism,
+		memory:      memory,
+		iterations:  iterations,
+	}
+}
+
+func (e *Argon2PasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *Argon2PasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *Argon2PasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *Argon2PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Argon2PasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *Argon2PasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Argon2PasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	salt, err := generateSalt(e.saltGen)
+	if err != nil {
+		return "", err
+	}
+	hash := argon2.IDKey(rawPassword, salt, uint32(e.iterations), uint32(e.memory), uint8(e.parallelism), uint32(e.hashLength))
+	return e.encode(argon2Params{"argon2id", e.memory, e.iterations, e.parallelism}, salt, hash), nil
+}
+
+func (e *Argon2PasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	params, salt, hash, ok := e.decode(encodedPassword)
+	if !ok {
+		return false, nil
+	}
+
+	var generated []byte
+	switch params.variant {
+	case "argon2id":
+		generated = argon2.IDKey(rawPassword, salt, uint32(params.iterations), uint32(params.memory), uint8(params.parallelism), uint32(len(hash)))
+	default: // argon2i
+		generated = argon2.Key(rawPassword, salt, uint32(params.iterations), uint32(params.memory), uint8(params.parallelism), uint32(len(hash)))
+	}
+	return bytes.Equal(hash, generated), nil
+}
+
+func (e *Argon2PasswordEncoder) encode(params argon2Params, salt, hash []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		params.variant, argon2.Version, params.memory, params.iterations, params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+}
+
+// true if the memory or iterations are lower than configured, as Spring does
+func (e *Argon2PasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	params, _, _, ok := e.decode(encodedPassword)
+	if !ok {
+		return false
+	}
+	return params.memory < e.memory || params.iterations < e.iterations
+}
+
+// EstimateMemory returns the memory parameter in bytes
+func (e *Argon2PasswordEncoder) EstimateMemory(encodedPassword string) int64 {
+	if encodedPassword == "" {
+		return int64(e.memory) * 1024
+	}
+	params, _, _, ok := e.decode(encodedPassword)
+	if !ok {
+		return 0
+	}
+	return int64(params.memory) * 1024
+}
+
+func (e *Argon2PasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	info, err := inspectArgon2(encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Strength = e.Strength()
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+func (e *Argon2PasswordEncoder) Strength() Strength {
+	return StrengthStrong
+}
+
+type argon2Params struct {
+	variant     string // argon2id or argon2i
+	memory      int
+	iterations  int
+	parallelism int
+}
+
+// decode "$argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<base64 salt>$<base64 hash>"
+func (e *Argon2PasswordEncoder) decode(encodedPassword string) (params argon2Params, salt, hash []byte, ok bool) {
+	parts := strings.Split(encodedPassword, "$")
+	if len(parts) != 6 { // ["", variant, version, params, salt, hash]
+		return argon2Params{}, nil, nil, false
+	}
+
+	params.variant = parts[1]
+	if params.variant != "argon2id" && params.variant != "argon2i" {
+		return argon2Params{}, nil, nil, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Params{}, nil, nil, false
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return argon2Params{}, nil, nil, false
+	}
+	if params.iterations < 1 || params.parallelism < 1 || params.parallelism > 255 || params.memory < 8*params.parallelism {
+		return argon2Params{}, nil, nil, false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, nil, nil, false
+	}
+
+	hash, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return argon2Params{}, nil, nil, false
+	}
+
+	return params, salt, hash, true
+}
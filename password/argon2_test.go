@@ -0,0 +1,119 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/keygen/keygentest"
+)
+
+func TestNewArgon2PasswordEncoder(t *testing.T) {
+	t.Run("panics salt too short", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewArgon2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength-1), 32, 1, 1<<14, 2)
+		})
+	})
+
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(16)
+	t.Run("panics iterations < 1", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewArgon2PasswordEncoder(saltGen, 32, 1, 1<<14, 0)
+		})
+	})
+
+	t.Run("panics parallelism < 1", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewArgon2PasswordEncoder(saltGen, 32, 0, 1<<14, 2)
+		})
+	})
+
+	t.Run("panics parallelism > 255", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewArgon2PasswordEncoder(saltGen, 32, 256, 1<<14, 2)
+		})
+	})
+
+	t.Run("panics memory < 8*parallelism", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewArgon2PasswordEncoder(saltGen, 32, 4, 31, 2)
+		})
+	})
+
+	t.Run("ok minimums", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			NewArgon2PasswordEncoder(saltGen, 32, 255, 8*255, 1)
+		})
+	})
+}
+
+func TestArgon2PasswordEncoder_Matches(t *testing.T) {
+	encoder := NewArgon2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 32, 1, 64, 1)
+
+	t.Run("ok", func(t *testing.T) {
+		rawPassword := "password"
+		encodedPassword, err := encoder.Encode(rawPassword)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encodedPassword, "$argon2id$v=19$m=64,t=1,p=1$"))
+		assert.True(t, encoder.Matches(rawPassword, encodedPassword))
+		assert.False(t, encoder.Matches(rawPassword+"a", encodedPassword))
+
+		parts := strings.Split(encodedPassword, "$")
+		assert.Len(t, parts, 6)
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", "argon2d", parts[2], parts[3], parts[4], parts[5]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], "v=16", parts[3], parts[4], parts[5]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], "m=64,t=0,p=1", parts[4], parts[5]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], "m=4,t=1,p=1", parts[4], parts[5]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], "m=64,t=1", parts[4], parts[5]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], parts[3], "_", parts[5]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "_"}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], ""}, "$")))
+
+		assert.False(t, encoder.Matches(rawPassword, ""))
+	})
+
+	t.Run("reference implementation", func(t *testing.T) {
+		// phc-winner-argon2 src/test.c
+		assert.True(t, encoder.Matches("password", "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"))
+		assert.True(t, encoder.Matches("password", "$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA"))
+		assert.False(t, encoder.Matches("differentpassword", "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"))
+	})
+}
+
+func TestArgon2PasswordEncoder_Encode(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		encodedPassword, err := DefaultArgon2PasswordEncoder().Encode("password")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encodedPassword, "$argon2id$v=19$m=16384,t=2,p=1$"))
+	})
+
+	t.Run("err saltGen", func(t *testing.T) {
+		encoder := NewArgon2PasswordEncoder(keygentest.ErrBytesKeyGenerator(errors.New("oops"), 16), 32, 1, 64, 1)
+		_, err := encoder.Encode("password")
+		assert.Error(t, err)
+	})
+}
+
+func TestArgon2PasswordEncoder_UpgradeEncoding(t *testing.T) {
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(16)
+	encoder := NewArgon2PasswordEncoder(saltGen, 32, 1, 64, 2)
+
+	encodedPassword, err := encoder.Encode("password")
+	assert.NoError(t, err)
+	assert.Equal(t, false, encoder.UpgradeEncoding(encodedPassword))
+
+	assert.Equal(t, true, NewArgon2PasswordEncoder(saltGen, 32, 1, 128, 2).UpgradeEncoding(encodedPassword))
+	assert.Equal(t, true, NewArgon2PasswordEncoder(saltGen, 32, 1, 64, 3).UpgradeEncoding(encodedPassword))
+	assert.Equal(t, false, encoder.UpgradeEncoding(""))
+}
+
+func TestArgon2PasswordEncoder_EstimateMemory(t *testing.T) {
+	encoder := DefaultArgon2PasswordEncoder()
+	assert.Equal(t, int64(16<<20), encoder.EstimateMemory(""))
+	assert.Equal(t, int64(64<<20), encoder.EstimateMemory("$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"))
+	assert.Equal(t, int64(0), encoder.EstimateMemory("$argon2id$"))
+}
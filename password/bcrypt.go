@@ -1,31 +1,289 @@
 package password
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/blowfish"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// bcrypt uses at most 72 bytes of the password
+const bcryptMaxPasswordLen = 72
+
+// LongPasswordMode is how BCryptPasswordEncoder encodes passwords longer than 72 bytes.
+type LongPasswordMode int
+
+const (
+	// LongPasswordReject makes Encode return a *PasswordTooLongError, the default
+	LongPasswordReject LongPasswordMode = iota
+	// LongPasswordPreHash encodes all passwords as bcrypt(base64(HMAC-SHA-384(password))),
+	// like Django's BCryptSHA256PasswordHasher and Passlib's bcrypt_sha256,
+	// recorded as "$sha384$2a$10$..."
+	LongPasswordPreHash
+)
+
+// PasswordTooLongError is returned by BCryptPasswordEncoder.Encode with LongPasswordReject,
+// errors.Is(err, bcrypt.ErrPasswordTooLong) is true.
+type PasswordTooLongError struct {
+	Length int
+	Max    int
+}
+
+func (e *PasswordTooLongError) Error() string {
+	return fmt.Sprintf("password: password length %d exceeds %d bytes", e.Length, e.Max)
+}
+
+func (e *PasswordTooLongError) Unwrap() error {
+	return bcrypt.ErrPasswordTooLong
+}
+
+// BCryptVersion is the minor version of encoded passwords, as in Spring.
+// The hash is the same, $2a$ is read by most implementations, e.g. Spring, $2y$ by PHP's crypt_blowfish.
+type BCryptVersion string
+
+const (
+	BCryptVersion2a BCryptVersion = "2a"
+	BCryptVersion2b BCryptVersion = "2b"
+	BCryptVersion2y BCryptVersion = "2y"
+)
+
+// bcrypt salts are 16 bytes
+const bcryptSaltLen = 16
+
+type BCryptOption func(e *BCryptPasswordEncoder)
+
+// WithBCryptVersion encodes passwords as version, BCryptVersion2a by default
+func WithBCryptVersion(version BCryptVersion) BCryptOption {
+	return func(e *BCryptPasswordEncoder) {
+		e.version = version
+	}
+}
+
+// WithBCryptSaltGenerator generates the 16 bytes salts with saltGen instead of crypto/rand,
+// e.g. for deterministic tests
+func WithBCryptSaltGenerator(saltGen keygen.BytesKeyGenerator) BCryptOption {
+	return func(e *BCryptPasswordEncoder) {
+		e.saltGen = saltGen
+	}
+}
+
+func WithLongPasswordMode(mode LongPasswordMode) BCryptOption {
+	return func(e *BCryptPasswordEncoder) {
+		e.longPasswordMode = mode
+	}
+}
+
+// BCryptPasswordEncoder matches passwords encoded in either LongPasswordMode.
+//
+// Passwords longer than 72 bytes match plain bcrypt hashes by their first 72 bytes,
+// as such hashes were created by bcrypt implementations that truncated silently.
+// MatchesTruncated reports those matches, which UpgradeEncoding cannot see without the raw password.
 type BCryptPasswordEncoder struct {
-	cost int // cost is exponential
+	cost    int // cost is exponential
+	version BCryptVersion
+	saltGen keygen.BytesKeyGenerator
+
+	longPasswordMode LongPasswordMode
 }
 
-func NewBCryptPasswordEncoder(cost int) *BCryptPasswordEncoder {
-	return &BCryptPasswordEncoder{cost: cost}
+// panics if the version is unknown or saltGen does not generate 16 bytes keys
+func NewBCryptPasswordEncoder(cost int, opts ...BCryptOption) *BCryptPasswordEncoder {
+	e := &BCryptPasswordEncoder{
+		cost:    cost,
+		version: BCryptVersion2a,
+		saltGen: keygen.NewSecureRandomBytesKeyGenerator(bcryptSaltLen),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	switch e.version {
+	case BCryptVersion2a, BCryptVersion2b, BCryptVersion2y:
+	default:
+		panic(fmt.Errorf("unknown bcrypt version %q", e.version))
+	}
+	if e.saltGen.KeyLength() != bcryptSaltLen {
+		panic(fmt.Errorf("salt length %d of saltGen is not %d", e.saltGen.KeyLength(), bcryptSaltLen))
+	}
+	return e
 }
 
-var _ PasswordEncoder = (*BCryptPasswordEncoder)(nil)
+var _ ContextPasswordEncoder = (*BCryptPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*BCryptPasswordEncoder)(nil)
 
 func (e *BCryptPasswordEncoder) Encode(rawPassword string) (string, error) {
-	encodedPassword, err := bcrypt.GenerateFromPassword([]byte(rawPassword), e.cost)
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *BCryptPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *BCryptPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *BCryptPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *BCryptPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *BCryptPasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *BCryptPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	prefix := ""
+	if e.longPasswordMode == LongPasswordPreHash {
+		rawPassword = bcryptPreHash(rawPassword)
+		defer zero(rawPassword)
+		prefix = bcryptSha384Prefix
+	} else if len(rawPassword) > bcryptMaxPasswordLen {
+		return "", &PasswordTooLongError{Length: len(rawPassword), Max: bcryptMaxPasswordLen}
+	}
+
+	cost := e.encodeCost()
+	if cost > bcrypt.MaxCost {
+		return "", bcrypt.InvalidCostError(cost)
+	}
+
+	salt, err := generateSalt(e.saltGen)
 	if err != nil {
 		return "", err
 	}
-	return string(encodedPassword), nil
+	hash, err := bcryptHash(rawPassword, cost, salt)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s$%s$%02d$%s%s", prefix, e.version, cost, bcryptEncoding.EncodeToString(salt), hash), nil
 }
 
-func (e *BCryptPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(encodedPassword), []byte(rawPassword))
-	return err == nil
+func (e *BCryptPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if strings.HasPrefix(encodedPassword, bcryptSha384Prefix) {
+		rawPassword = bcryptPreHash(rawPassword)
+		defer zero(rawPassword)
+		encodedPassword = strings.TrimPrefix(encodedPassword, bcryptSha384Prefix)
+	} else if len(rawPassword) > bcryptMaxPasswordLen {
+		rawPassword = rawPassword[:bcryptMaxPasswordLen] // a truncated hash
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(encodedPassword), rawPassword)
+	return err == nil, nil
 }
 
+// MatchesTruncated is Matches, also reporting whether only the first 72 bytes of rawPassword matched
+// a plain bcrypt hash. The hash should then be replaced: with LongPasswordReject Encode rejects rawPassword,
+// so the user has to change it, with LongPasswordPreHash UpgradeEncoding is true anyway.
+func (e *BCryptPasswordEncoder) MatchesTruncated(rawPassword string, encodedPassword string) (matched bool, truncated bool) {
+	matched = e.Matches(rawPassword, encodedPassword)
+	truncated = matched && len(rawPassword) > bcryptMaxPasswordLen && !strings.HasPrefix(encodedPassword, bcryptSha384Prefix)
+	return matched, truncated
+}
+
+// true if the cost is lower than configured, as Spring does, if the version is not the configured one,
+// or for plain bcrypt hashes with LongPasswordPreHash
 func (e *BCryptPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
-	// TODO: compare cost
-	return false
+	if e.longPasswordMode == LongPasswordPreHash && !strings.HasPrefix(encodedPassword, bcryptSha384Prefix) {
+		return true
+	}
+	encodedPassword = strings.TrimPrefix(encodedPassword, bcryptSha384Prefix)
+	cost, err := bcrypt.Cost([]byte(encodedPassword))
+	if err != nil {
+		return false
+	}
+	return cost < e.encodeCost() || !strings.HasPrefix(encodedPassword, "$"+string(e.version)+"$")
+}
+
+// the cost of Encode, bcrypt.DefaultCost below bcrypt.MinCost as bcrypt.GenerateFromPassword
+func (e *BCryptPasswordEncoder) encodeCost() int {
+	if e.cost < bcrypt.MinCost {
+		return bcrypt.DefaultCost
+	}
+	return e.cost
+}
+
+func (e *BCryptPasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	info, err := inspectBCrypt(encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Strength = e.Strength()
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+func (e *BCryptPasswordEncoder) Strength() Strength {
+	return StrengthStrong
+}
+
+const bcryptSha384Prefix = "$sha384"
+
+// bcryptPreHashKey is the HMAC key of bcryptPreHash, separating it from plain SHA-384
+var bcryptPreHashKey = []byte("bcrypt-sha384")
+
+// return base64(hmac-sha384(rawPassword)), 64 bytes without NUL which fits bcrypt's 72 bytes limit
+func bcryptPreHash(rawPassword []byte) []byte {
+	mac := hmac.New(sha512.New384, bcryptPreHashKey)
+	mac.Write(rawPassword)
+	sum := mac.Sum(nil)
+	defer zero(sum)
+
+	preHashed := make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+	base64.StdEncoding.Encode(preHashed, sum)
+	return preHashed
+}
+
+// bcrypt's base64 alphabet, without padding
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// encrypted 64 times with the expanded key
+var bcryptMagic = []byte("OrpheanBeholderScryDoubt")
+
+// bcryptHash is the EksBlowfish hash of golang.org/x/crypto/bcrypt, which takes no salt or version,
+// returning the last 31 chars of the encoded password.
+// rawPassword is at most 72 bytes, the cost is checked by the caller.
+func bcryptHash(rawPassword []byte, cost int, salt []byte) (string, error) {
+	// the trailing NUL of C strings is part of the key
+	key := make([]byte, len(rawPassword)+1)
+	copy(key, rawPassword)
+	defer zero(key)
+
+	c, err := blowfish.NewSaltedCipher(key, salt)
+	if err != nil {
+		return "", err
+	}
+	for i := uint64(0); i < 1<<uint(cost); i++ {
+		blowfish.ExpandKey(key, c)
+		blowfish.ExpandKey(salt, c)
+	}
+
+	cipherText := make([]byte, len(bcryptMagic))
+	copy(cipherText, bcryptMagic)
+	for i := 0; i < len(cipherText); i += blowfish.BlockSize {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(cipherText[i:i+blowfish.BlockSize], cipherText[i:i+blowfish.BlockSize])
+		}
+	}
+	// only 23 of the 24 bytes, as C implementations
+	return bcryptEncoding.EncodeToString(cipherText[:23]), nil
 }
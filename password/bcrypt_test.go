@@ -1,11 +1,15 @@
 package password
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
 )
 
 func TestBCryptPasswordEncoder_Matches(t *testing.T) {
@@ -36,7 +40,7 @@ func TestBCryptPasswordEncoder_Matches(t *testing.T) {
 }
 
 func TestBCryptPasswordEncoder_UpgradeEncoding(t *testing.T) {
-	t.Run("always false", func(t *testing.T) {
+	t.Run("same cost", func(t *testing.T) {
 		encoder := NewBCryptPasswordEncoder(bcrypt.DefaultCost)
 
 		encodedPassword, err := encoder.Encode("password")
@@ -44,4 +48,164 @@ func TestBCryptPasswordEncoder_UpgradeEncoding(t *testing.T) {
 
 		assert.Equal(t, false, encoder.UpgradeEncoding(encodedPassword))
 	})
+
+	t.Run("cost", func(t *testing.T) {
+		encodedPassword, err := NewBCryptPasswordEncoder(bcrypt.MinCost).Encode("password")
+		require.NoError(t, err)
+
+		assert.Equal(t, true, NewBCryptPasswordEncoder(bcrypt.MinCost+1).UpgradeEncoding(encodedPassword))
+		assert.Equal(t, false, NewBCryptPasswordEncoder(bcrypt.MinCost-1).UpgradeEncoding("$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG"))
+		assert.Equal(t, false, NewBCryptPasswordEncoder(bcrypt.MinCost+1).UpgradeEncoding("$2a$broken"))
+	})
+}
+
+func TestBCryptPasswordEncoder_LongPassword(t *testing.T) {
+	long := strings.Repeat("a", 72) + "b"
+
+	t.Run("reject", func(t *testing.T) {
+		encoder := NewBCryptPasswordEncoder(bcrypt.MinCost)
+
+		_, err := encoder.Encode(long)
+
+		var tooLong *PasswordTooLongError
+		require.True(t, errors.As(err, &tooLong))
+		assert.Equal(t, &PasswordTooLongError{Length: 73, Max: 72}, tooLong)
+		assert.True(t, errors.Is(err, bcrypt.ErrPasswordTooLong))
+
+		encodedPassword, err := encoder.Encode(long[:72])
+		require.NoError(t, err)
+		assert.True(t, encoder.Matches(long[:72], encodedPassword))
+	})
+
+	t.Run("pre-hash", func(t *testing.T) {
+		encoder := NewBCryptPasswordEncoder(bcrypt.MinCost, WithLongPasswordMode(LongPasswordPreHash))
+
+		encodedPassword, err := encoder.Encode(long)
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(encodedPassword, "$sha384$2a$04$"))
+		assert.True(t, encoder.Matches(long, encodedPassword))
+		assert.False(t, encoder.Matches(long[:72], encodedPassword))
+		assert.False(t, encoder.Matches(long+"c", encodedPassword))
+		assert.False(t, encoder.UpgradeEncoding(encodedPassword))
+
+		// also verified without the option
+		assert.True(t, NewBCryptPasswordEncoder(bcrypt.MinCost).Matches(long, encodedPassword))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		// as encoded by bcrypt implementations that truncated silently
+		truncated, err := bcrypt.GenerateFromPassword([]byte(long[:72]), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		encoder := NewBCryptPasswordEncoder(bcrypt.MinCost, WithLongPasswordMode(LongPasswordPreHash))
+		assert.True(t, encoder.Matches(long, string(truncated)))
+		assert.True(t, encoder.Matches(long[:72], string(truncated)))
+		assert.False(t, encoder.Matches(long[:71], string(truncated)))
+		assert.True(t, encoder.UpgradeEncoding(string(truncated)))
+	})
+
+	t.Run("MatchesTruncated", func(t *testing.T) {
+		truncated, err := bcrypt.GenerateFromPassword([]byte(long[:72]), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		encoder := NewBCryptPasswordEncoder(bcrypt.MinCost)
+		matched, wasTruncated := encoder.MatchesTruncated(long, string(truncated))
+		assert.True(t, matched)
+		assert.True(t, wasTruncated)
+		assert.False(t, encoder.UpgradeEncoding(string(truncated)))
+
+		matched, wasTruncated = encoder.MatchesTruncated(long[:72], string(truncated))
+		assert.True(t, matched)
+		assert.False(t, wasTruncated)
+
+		matched, wasTruncated = encoder.MatchesTruncated(long[:71], string(truncated))
+		assert.False(t, matched)
+		assert.False(t, wasTruncated)
+
+		preHashed, err := NewBCryptPasswordEncoder(bcrypt.MinCost, WithLongPasswordMode(LongPasswordPreHash)).Encode(long)
+		require.NoError(t, err)
+		matched, wasTruncated = encoder.MatchesTruncated(long, preHashed)
+		assert.True(t, matched)
+		assert.False(t, wasTruncated)
+	})
+}
+
+func TestBCryptPasswordEncoder_Inspect(t *testing.T) {
+	encoder := NewBCryptPasswordEncoder(bcrypt.MinCost, WithLongPasswordMode(LongPasswordPreHash))
+
+	encodedPassword, err := encoder.Encode("password")
+	require.NoError(t, err)
+
+	info, err := encoder.Inspect(encodedPassword)
+	require.NoError(t, err)
+	assert.Equal(t, HashInfo{Algorithm: AlgorithmBCryptSha384, Strength: StrengthStrong, Version: "2a", Cost: 4, SaltLength: 16, KeyLength: 23}, info)
+
+	info, err = Inspect("{bcrypt}" + encodedPassword)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmBCryptSha384, info.Algorithm)
+
+	_, err = encoder.Inspect("$sha384$2a$04$short")
+	assert.ErrorIs(t, err, ErrUnrecognizedEncoding)
+}
+
+func TestBCryptPasswordEncoder_Version(t *testing.T) {
+	// from the OpenBSD test vectors
+	const encodedPassword = "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW"
+	salt, err := bcryptEncoding.DecodeString("CCCCCCCCCCCCCCCCCCCCC.")
+	require.NoError(t, err)
+	saltGen := keygen.NewSharedKeyGenerator(salt)
+
+	tests := []struct {
+		version BCryptVersion
+		want    string
+	}{
+		{version: BCryptVersion2a, want: encodedPassword},
+		{version: BCryptVersion2b, want: "$2b$" + encodedPassword[4:]},
+		{version: BCryptVersion2y, want: "$2y$" + encodedPassword[4:]},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.version), func(t *testing.T) {
+			encoder := NewBCryptPasswordEncoder(5, WithBCryptVersion(tt.version), WithBCryptSaltGenerator(saltGen))
+
+			got, err := encoder.Encode("U*U")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, encoder.Matches("U*U", got))
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(got), []byte("U*U")))
+
+			info, err := encoder.Inspect(got)
+			require.NoError(t, err)
+			assert.Equal(t, string(tt.version), info.Version)
+
+			assert.False(t, encoder.UpgradeEncoding(got))
+			for _, other := range tests {
+				if other.version != tt.version {
+					assert.True(t, encoder.UpgradeEncoding(other.want), other.version)
+				}
+			}
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		encodedPassword, err := NewBCryptPasswordEncoder(bcrypt.MinCost).Encode("password")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encodedPassword, "$2a$04$"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Panics(t, func() { NewBCryptPasswordEncoder(bcrypt.MinCost, WithBCryptVersion("2x")) })
+		assert.Panics(t, func() {
+			NewBCryptPasswordEncoder(bcrypt.MinCost, WithBCryptSaltGenerator(keygen.NewSecureRandomBytesKeyGenerator(8)))
+		})
+	})
+}
+
+func TestBCryptHash(t *testing.T) {
+	// as golang.org/x/crypto/bcrypt, up to 72 bytes
+	for _, rawPassword := range []string{"", "password", "パスワード", strings.Repeat("x", 72)} {
+		encodedPassword, err := NewBCryptPasswordEncoder(bcrypt.MinCost).Encode(rawPassword)
+		require.NoError(t, err)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(encodedPassword), []byte(rawPassword)), rawPassword)
+	}
 }
@@ -0,0 +1,130 @@
+package password
+
+import (
+	"context"
+	"encoding/hex"
+)
+
+// BytesPasswordEncoder is a PasswordEncoder that takes the raw password as a byte slice,
+// which the caller can zero after use, unlike an immutable string. See RawPassword.
+//
+// The encoders of this package zero their intermediate buffers of the raw password,
+// e.g. the salt and password concatenation of Sha256PasswordEncoder, but not those of the
+// underlying implementations, e.g. the HMAC keys of PBKDF2 or the buffers of bcrypt and scrypt.
+type BytesPasswordEncoder interface {
+	PasswordEncoder
+
+	EncodeBytes(rawPassword []byte) (string, error)
+
+	MatchesBytes(rawPassword []byte, encodedPassword string) bool
+}
+
+// EncodeBytes calls encoder.EncodeBytes if encoder is a BytesPasswordEncoder,
+// otherwise encoder.Encode with a string copy of rawPassword that cannot be zeroed.
+func EncodeBytes(encoder PasswordEncoder, rawPassword []byte) (string, error) {
+	if e, ok := encoder.(BytesPasswordEncoder); ok {
+		return e.EncodeBytes(rawPassword)
+	}
+	return encoder.Encode(string(rawPassword))
+}
+
+// MatchesBytes calls encoder.MatchesBytes if encoder is a BytesPasswordEncoder,
+// otherwise encoder.Matches with a string copy of rawPassword that cannot be zeroed.
+func MatchesBytes(encoder PasswordEncoder, rawPassword []byte, encodedPassword string) bool {
+	if e, ok := encoder.(BytesPasswordEncoder); ok {
+		return e.MatchesBytes(rawPassword, encodedPassword)
+	}
+	return encoder.Matches(string(rawPassword), encodedPassword)
+}
+
+// bytesContextEncoder is the byte-based implementation of the encoders of this package,
+// which their string, context and byte methods share.
+type bytesContextEncoder interface {
+	encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error)
+
+	matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error)
+}
+
+// encodeBytesContext is EncodeContext for decorators passing on rawPassword
+func encodeBytesContext(ctx context.Context, encoder PasswordEncoder, rawPassword []byte) (string, error) {
+	if e, ok := encoder.(bytesContextEncoder); ok {
+		return e.encodeBytesContext(ctx, rawPassword)
+	}
+	if e, ok := encoder.(ContextPasswordEncoder); ok {
+		return e.EncodeContext(ctx, string(rawPassword))
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return EncodeBytes(encoder, rawPassword)
+}
+
+// matchesBytesContext is MatchesContext for decorators passing on rawPassword
+func matchesBytesContext(ctx context.Context, encoder PasswordEncoder, rawPassword []byte, encodedPassword string) (bool, error) {
+	if e, ok := encoder.(bytesContextEncoder); ok {
+		return e.matchesBytesContext(ctx, rawPassword, encodedPassword)
+	}
+	if e, ok := encoder.(ContextPasswordEncoder); ok {
+		return e.MatchesContext(ctx, string(rawPassword), encodedPassword)
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return MatchesBytes(encoder, rawPassword, encodedPassword), nil
+}
+
+// encodeStringContext is EncodeContext of a bytesContextEncoder. The byte copy of rawPassword is not zeroed,
+// the immutable string it is copied from cannot be either.
+func encodeStringContext(ctx context.Context, encoder bytesContextEncoder, rawPassword string) (string, error) {
+	return encoder.encodeBytesContext(ctx, []byte(rawPassword))
+}
+
+// matchesStringContext is MatchesContext of a bytesContextEncoder, see encodeStringContext
+func matchesStringContext(ctx context.Context, encoder bytesContextEncoder, rawPassword string, encodedPassword string) (bool, error) {
+	return encoder.matchesBytesContext(ctx, []byte(rawPassword), encodedPassword)
+}
+
+// hexBytes is hex.EncodeToString as a byte slice that can be zeroed
+func hexBytes(b []byte) []byte {
+	dst := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(dst, b)
+	return dst
+}
+
+// zero overwrites b, e.g. a copy of a raw password
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// RawPassword holds a raw password as bytes that Destroy zeroes,
+// e.g. as read by golang.org/x/term.ReadPassword.
+//
+// It is redacted by fmt. Pass Bytes to EncodeBytes or MatchesBytes, then call Destroy.
+type RawPassword struct {
+	b []byte
+}
+
+// NewRawPassword takes ownership of b, which Destroy zeroes
+func NewRawPassword(b []byte) *RawPassword {
+	return &RawPassword{b: b}
+}
+
+// Bytes returns the raw password, nil after Destroy
+func (p *RawPassword) Bytes() []byte {
+	return p.b
+}
+
+func (p *RawPassword) Destroy() {
+	zero(p.b)
+	p.b = nil
+}
+
+func (p *RawPassword) String() string {
+	return redacted
+}
+
+func (p *RawPassword) GoString() string {
+	return "password.RawPassword(" + redacted + ")"
+}
@@ -0,0 +1,106 @@
+package password
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// the encoders of this package, with the string, context and byte methods
+type bytesContextPasswordEncoder interface {
+	ContextPasswordEncoder
+	BytesPasswordEncoder
+}
+
+// testEncoders are fast encoders of each type, shared by the tests of the string, context and byte methods
+func testEncoders() map[string]bytesContextPasswordEncoder {
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(16)
+	return map[string]bytesContextPasswordEncoder{
+		"nop":         NopPasswordEncoder().(bytesContextPasswordEncoder),
+		"bcrypt":      NewBCryptPasswordEncoder(bcrypt.MinCost),
+		"sha256":      NewSha256PasswordEncoder(saltGen),
+		"sm3":         NewSm3PasswordEncoder(saltGen),
+		"pbkdf2":      NewPbkdf2PasswordEncoder(saltGen, 1, sha256.Size, sha256.New),
+		"scrypt":      NewSCryptPasswordEncoder(saltGen, 16, 8, 1, 32),
+		"sm3pbkdf2":   NewSm3Pbkdf2PasswordEncoder(saltGen, 1, sm3.Size),
+		"argon2":      NewArgon2PasswordEncoder(saltGen, 32, 1, 64, 1),
+		"normalizing": NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), OpaqueString),
+		"nfkc":        NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), NFKC),
+		"onion":       NewOnionPasswordEncoder(NewSha256PasswordEncoder(saltGen), NewBCryptPasswordEncoder(bcrypt.MinCost)),
+		"pepper":      NewPepperPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), "k1", map[string][]byte{"k1": []byte("pepper")}),
+		"encrypting": NewEncryptingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), "k1", map[string]cipher.AEAD{
+			"k1": mustAead(NewAesGcm(make([]byte, 32))),
+		}),
+		"delegating": NewDelegatingPasswordEncoder("bcrypt", map[string]PasswordEncoder{
+			"bcrypt": NewBCryptPasswordEncoder(bcrypt.MinCost),
+		}),
+		"limited": NewLimitedPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), 1),
+		"plain delegate": NewDelegatingPasswordEncoder("bcrypt", map[string]PasswordEncoder{
+			"bcrypt": plainPasswordEncoder{NewBCryptPasswordEncoder(bcrypt.MinCost)},
+		}),
+	}
+}
+
+func TestBytesPasswordEncoder(t *testing.T) {
+	for name, encoder := range testEncoders() {
+		t.Run(name, func(t *testing.T) {
+			rawPassword := []byte("password")
+
+			encodedPassword, err := encoder.EncodeBytes(rawPassword)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("password"), rawPassword, "not modified")
+
+			assert.True(t, encoder.MatchesBytes(rawPassword, encodedPassword))
+			assert.True(t, encoder.Matches("password", encodedPassword))
+			assert.False(t, encoder.MatchesBytes([]byte("password1"), encodedPassword))
+			assert.Equal(t, []byte("password"), rawPassword, "not modified")
+
+			encodedPassword, err = encoder.Encode("password")
+			require.NoError(t, err)
+			assert.True(t, encoder.MatchesBytes(rawPassword, encodedPassword))
+			assert.Equal(t, []byte("password"), rawPassword, "not modified")
+		})
+	}
+}
+
+func TestEncodeBytes(t *testing.T) {
+	encoder := plainPasswordEncoder{NewBCryptPasswordEncoder(bcrypt.MinCost)}
+
+	encodedPassword, err := EncodeBytes(encoder, []byte("password"))
+	require.NoError(t, err)
+	assert.True(t, MatchesBytes(encoder, []byte("password"), encodedPassword))
+	assert.False(t, MatchesBytes(encoder, []byte("password1"), encodedPassword))
+}
+
+func TestSha256PasswordEncoder_digest(t *testing.T) {
+	salt := make([]byte, 8, 64) // spare capacity must not be written
+	encoder := &Sha256PasswordEncoder{}
+	saltDigest := encoder.digest([]byte("password"), salt)
+
+	expected := sha256.Sum256(append(make([]byte, 8), "password"...))
+	assert.Equal(t, append(make([]byte, 8), expected[:]...), saltDigest)
+	assert.Equal(t, make([]byte, 64), salt[:64])
+}
+
+func TestRawPassword(t *testing.T) {
+	b := []byte("password")
+	rawPassword := NewRawPassword(b)
+	assert.Equal(t, "password", string(rawPassword.Bytes()))
+
+	assert.Equal(t, "[REDACTED]", fmt.Sprintf("%v", rawPassword))
+	assert.Equal(t, "password.RawPassword([REDACTED])", fmt.Sprintf("%#v", rawPassword))
+	assert.NotContains(t, fmt.Sprintf("%+v", struct{ P *RawPassword }{rawPassword}), "password")
+
+	rawPassword.Destroy()
+	assert.Equal(t, make([]byte, 8), b)
+	assert.Nil(t, rawPassword.Bytes())
+	rawPassword.Destroy()
+}
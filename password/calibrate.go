@@ -0,0 +1,306 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+const (
+	DefaultCalibrationDuration = 250 * time.Millisecond
+	// DefaultCalibrationMemory reaches scrypt N=2^15 with r=8, N=2^16 needs
+	// 128*8*(2^16+4) bytes, just over 64 MiB, see CalibrationTarget.MaxMemory.
+	DefaultCalibrationMemory = 64 << 20 // 64 MiB
+)
+
+// OWASP Password Storage Cheat Sheet minimums
+//
+// https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html
+const (
+	owaspBCryptMinCost = 10
+
+	owaspPbkdf2Sha1MinIterations   = 1300000
+	owaspPbkdf2Sha256MinIterations = 600000
+	owaspPbkdf2Sha512MinIterations = 210000
+)
+
+// scrypt N (with r=8) and the minimum p for it, equivalent in strength
+var owaspSCryptMinimums = []struct{ cpuCost, parallelization int }{
+	{1 << 17, 1},
+	{1 << 16, 2},
+	{1 << 15, 3},
+	{1 << 14, 5},
+	{1 << 13, 10},
+}
+
+// argon2id memory in KiB (with p=1) and the minimum t for it, equivalent in strength
+var owaspArgon2Minimums = []struct{ memory, iterations int }{
+	{47104, 1},
+	{19456, 2},
+	{12288, 3},
+	{9216, 4},
+	{7168, 5},
+}
+
+const calibrationPassword = "calibration-password"
+
+var calibrationSalt = make([]byte, 16)
+
+// CalibrationTarget is what the Calibrate functions aim for on the current machine.
+type CalibrationTarget struct {
+	Duration  time.Duration // of one Encode, DefaultCalibrationDuration if 0
+	MaxMemory int64         // bytes of one Encode for scrypt and argon2, DefaultCalibrationMemory if 0, e.g. 65 << 20 for scrypt N=2^16
+}
+
+func (t CalibrationTarget) duration() time.Duration {
+	if t.Duration <= 0 {
+		return DefaultCalibrationDuration
+	}
+	return t.Duration
+}
+
+func (t CalibrationTarget) maxMemory() int64 {
+	if t.MaxMemory <= 0 {
+		return DefaultCalibrationMemory
+	}
+	return t.MaxMemory
+}
+
+type BCryptCalibration struct {
+	Cost     int
+	Duration time.Duration // measured
+}
+
+func (c BCryptCalibration) Encoder() *BCryptPasswordEncoder {
+	return NewBCryptPasswordEncoder(c.Cost)
+}
+
+// CalibrateBCrypt returns the highest cost not exceeding target.Duration, at least 10.
+func CalibrateBCrypt(target CalibrationTarget) (BCryptCalibration, error) {
+	bcryptCost := func(cost int) (time.Duration, error) {
+		return measure(func() error {
+			_, err := bcrypt.GenerateFromPassword([]byte(calibrationPassword), cost)
+			return err
+		})
+	}
+
+	c := BCryptCalibration{Cost: owaspBCryptMinCost}
+	d, err := bcryptCost(c.Cost)
+	if err != nil {
+		return BCryptCalibration{}, err
+	}
+	// each increment doubles the duration
+	for c.Cost < bcrypt.MaxCost && 2*d <= target.duration() {
+		c.Cost++
+		if d, err = bcryptCost(c.Cost); err != nil {
+			return BCryptCalibration{}, err
+		}
+	}
+	c.Duration = d
+	return c, nil
+}
+
+type Pbkdf2Calibration struct {
+	Iterations int
+	Duration   time.Duration // measured
+
+	h func() hash.Hash
+}
+
+// Encoder returns a Pbkdf2PasswordEncoder with a 16 bytes salt and the digest size as key length
+func (c Pbkdf2Calibration) Encoder() *Pbkdf2PasswordEncoder {
+	return NewPbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), c.Iterations, c.h().Size(), c.h)
+}
+
+// CalibratePbkdf2 returns the iterations reaching target.Duration, at least the OWASP minimum for h:
+// 1300000 for SHA-1, 210000 for SHA-512 and 600000 otherwise (SHA-256, SM3).
+func CalibratePbkdf2(target CalibrationTarget, h func() hash.Hash) (Pbkdf2Calibration, error) {
+	pbkdf2Iterations := func(iter int) (time.Duration, error) {
+		return measure(func() error {
+			_, err := pbkdf2Key(context.Background(), []byte(calibrationPassword), calibrationSalt, iter, h().Size(), h)
+			return err
+		})
+	}
+
+	minIterations := owaspPbkdf2Sha256MinIterations
+	switch h().Size() {
+	case sha1.Size:
+		minIterations = owaspPbkdf2Sha1MinIterations
+	case sha512.Size:
+		minIterations = owaspPbkdf2Sha512MinIterations
+	}
+
+	// the duration is linear in iterations
+	probeIterations := 10000
+	probe, err := pbkdf2Iterations(probeIterations)
+	if err != nil {
+		return Pbkdf2Calibration{}, err
+	}
+	c := Pbkdf2Calibration{Iterations: int(float64(probeIterations) * float64(target.duration()) / float64(probe)), h: h}
+	if c.Iterations < minIterations {
+		c.Iterations = minIterations
+	}
+
+	if c.Duration, err = pbkdf2Iterations(c.Iterations); err != nil {
+		return Pbkdf2Calibration{}, err
+	}
+	return c, nil
+}
+
+type SCryptCalibration struct {
+	CpuCost         int   // N
+	MemoryCost      int   // r
+	Parallelization int   // p
+	Memory          int64 // estimated bytes, see SCryptPasswordEncoder.EstimateMemory
+	Duration        time.Duration
+}
+
+// Encoder returns a SCryptPasswordEncoder with a 16 bytes salt and 32 bytes key
+func (c SCryptCalibration) Encoder() *SCryptPasswordEncoder {
+	return NewSCryptPasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), c.CpuCost, c.MemoryCost, c.Parallelization, 32)
+}
+
+// CalibrateSCrypt returns r=8 with the highest N within target.MaxMemory,
+// and p raised to reach target.Duration, at least the OWASP minimum p for N.
+// It returns an error if target.MaxMemory is below the OWASP minimum N=2^13.
+func CalibrateSCrypt(target CalibrationTarget) (SCryptCalibration, error) {
+	scryptCost := func(cpuCost, memoryCost, parallelization int) (time.Duration, error) {
+		return measure(func() error {
+			_, err := scrypt.Key([]byte(calibrationPassword), calibrationSalt, cpuCost, memoryCost, parallelization, 32)
+			return err
+		})
+	}
+
+	const memoryCost = 8
+	minParallelization := func(cpuCost int) int {
+		for _, m := range owaspSCryptMinimums {
+			if cpuCost >= m.cpuCost {
+				return m.parallelization
+			}
+		}
+		return 0
+	}
+
+	c := SCryptCalibration{MemoryCost: memoryCost}
+	for _, m := range owaspSCryptMinimums {
+		if scryptMemory(m.cpuCost, memoryCost, m.parallelization) <= target.maxMemory() {
+			c.CpuCost = m.cpuCost
+			break
+		}
+	}
+	if c.CpuCost == 0 {
+		return SCryptCalibration{}, fmt.Errorf("memory %d below OWASP minimum for scrypt", target.maxMemory())
+	}
+	c.Parallelization = minParallelization(c.CpuCost)
+
+	d, err := scryptCost(c.CpuCost, memoryCost, c.Parallelization)
+	if err != nil {
+		return SCryptCalibration{}, err
+	}
+
+	// doubling N doubles the duration and memory, for p=1
+	perParallelization := d / time.Duration(c.Parallelization)
+	if perParallelization <= 0 {
+		perParallelization = 1
+	}
+	for scryptMemory(2*c.CpuCost, memoryCost, 1) <= target.maxMemory() && 2*perParallelization*time.Duration(minParallelization(2*c.CpuCost)) <= target.duration() {
+		c.CpuCost *= 2
+		c.Parallelization = minParallelization(c.CpuCost)
+		perParallelization *= 2
+	}
+
+	// the duration is linear in p
+	if p := int(target.duration() / perParallelization); p > c.Parallelization {
+		c.Parallelization = p
+	}
+	if c.Parallelization > 255 {
+		c.Parallelization = 255 // encoded in 8 bits
+	}
+
+	c.Memory = scryptMemory(c.CpuCost, memoryCost, c.Parallelization)
+	if c.Duration, err = scryptCost(c.CpuCost, memoryCost, c.Parallelization); err != nil {
+		return SCryptCalibration{}, err
+	}
+	return c, nil
+}
+
+type Argon2Calibration struct {
+	Memory      int // KiB
+	Iterations  int
+	Parallelism int
+	Duration    time.Duration // measured
+}
+
+// Encoder returns an Argon2PasswordEncoder with a 16 bytes salt and 32 bytes hash
+func (c Argon2Calibration) Encoder() *Argon2PasswordEncoder {
+	return NewArgon2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 32, c.Parallelism, c.Memory, c.Iterations)
+}
+
+// CalibrateArgon2 returns argon2id with p=1, all of target.MaxMemory and the iterations reaching target.Duration,
+// at least the OWASP minimum t for the memory.
+// It returns an error if target.MaxMemory is below the OWASP minimum m=7 MiB.
+func CalibrateArgon2(target CalibrationTarget) (Argon2Calibration, error) {
+	argon2Cost := func(memory, iterations int) (time.Duration, error) {
+		return measure(func() error {
+			argon2.IDKey([]byte(calibrationPassword), calibrationSalt, uint32(iterations), uint32(memory), 1, 32)
+			return nil
+		})
+	}
+
+	c := Argon2Calibration{Parallelism: 1}
+	memory := target.maxMemory() / 1024
+	if memory > 1<<32-1 {
+		memory = 1<<32 - 1
+	}
+	c.Memory = int(memory)
+	for _, m := range owaspArgon2Minimums {
+		if c.Memory >= m.memory {
+			c.Iterations = m.iterations
+			break
+		}
+	}
+	if c.Iterations == 0 {
+		return Argon2Calibration{}, fmt.Errorf("memory %d below OWASP minimum for argon2", target.maxMemory())
+	}
+
+	// the duration is linear in iterations
+	d, err := argon2Cost(c.Memory, c.Iterations)
+	if err != nil {
+		return Argon2Calibration{}, err
+	}
+	if t := int(time.Duration(c.Iterations) * target.duration() / d); t > c.Iterations {
+		c.Iterations = t
+		if d, err = argon2Cost(c.Memory, c.Iterations); err != nil {
+			return Argon2Calibration{}, err
+		}
+	}
+	c.Duration = d
+	return c, nil
+}
+
+// the fastest of 2 runs, at least 1ns, or the first error
+func measure(f func() error) (time.Duration, error) {
+	var fastest time.Duration
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		if err := f(); err != nil {
+			return 0, err
+		}
+		if d := time.Since(start); i == 0 || d < fastest {
+			fastest = d
+		}
+	}
+	if fastest <= 0 {
+		fastest = 1
+	}
+	return fastest, nil
+}
@@ -0,0 +1,85 @@
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// a target below any OWASP minimum duration, so that the minimums apply
+var minimumTarget = CalibrationTarget{Duration: time.Nanosecond}
+
+func assertCalibratedEncoder(t *testing.T, encoder PasswordEncoder) {
+	encodedPassword, err := encoder.Encode("password")
+	require.NoError(t, err)
+	assert.True(t, encoder.Matches("password", encodedPassword))
+}
+
+func TestCalibrateBCrypt(t *testing.T) {
+	c, err := CalibrateBCrypt(minimumTarget)
+	require.NoError(t, err)
+	assert.Equal(t, 10, c.Cost)
+	assert.Greater(t, c.Duration, time.Duration(0))
+	assertCalibratedEncoder(t, c.Encoder())
+
+	encodedPassword, err := NewBCryptPasswordEncoder(bcrypt.MinCost).Encode("password")
+	require.NoError(t, err)
+	assert.True(t, c.Encoder().UpgradeEncoding(encodedPassword))
+}
+
+func TestCalibratePbkdf2(t *testing.T) {
+	c, err := CalibratePbkdf2(minimumTarget, sha256.New)
+	require.NoError(t, err)
+	assert.Equal(t, 600000, c.Iterations)
+	assert.Greater(t, c.Duration, time.Duration(0))
+
+	c, err = CalibratePbkdf2(minimumTarget, sha512.New)
+	require.NoError(t, err)
+	assert.Equal(t, 210000, c.Iterations)
+	assertCalibratedEncoder(t, c.Encoder())
+}
+
+func TestCalibrateSCrypt(t *testing.T) {
+	t.Run("memory bound", func(t *testing.T) {
+		c, err := CalibrateSCrypt(CalibrationTarget{Duration: time.Nanosecond, MaxMemory: 16 << 20})
+		require.NoError(t, err)
+		assert.Equal(t, 1<<13, c.CpuCost)
+		assert.Equal(t, 8, c.MemoryCost)
+		assert.Equal(t, 10, c.Parallelization)
+		assert.LessOrEqual(t, c.Memory, int64(16<<20))
+		assert.Equal(t, c.Encoder().EstimateMemory(""), c.Memory)
+		assertCalibratedEncoder(t, c.Encoder())
+
+		encodedPassword, err := NewSCryptPasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 1<<12, 8, 1, 32).Encode("password")
+		require.NoError(t, err)
+		assert.True(t, c.Encoder().UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("memory below minimum", func(t *testing.T) {
+		_, err := CalibrateSCrypt(CalibrationTarget{MaxMemory: 1 << 20})
+		assert.Error(t, err)
+	})
+}
+
+func TestCalibrateArgon2(t *testing.T) {
+	t.Run("memory bound", func(t *testing.T) {
+		c, err := CalibrateArgon2(CalibrationTarget{Duration: time.Nanosecond, MaxMemory: 8 << 20})
+		require.NoError(t, err)
+		assert.Equal(t, 8192, c.Memory)
+		assert.Equal(t, 5, c.Iterations)
+		assert.Equal(t, 1, c.Parallelism)
+		assertCalibratedEncoder(t, c.Encoder())
+	})
+
+	t.Run("memory below minimum", func(t *testing.T) {
+		_, err := CalibrateArgon2(CalibrationTarget{MaxMemory: 1 << 20})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,91 @@
+package password
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+)
+
+// ContextPasswordEncoder is a PasswordEncoder that respects context cancellation and deadlines.
+//
+// Work is not started on a done context, and aborted mid-computation where the algorithm allows,
+// e.g. in the PBKDF2 iteration loop. MatchesContext returns a non-nil error only if ctx is done.
+type ContextPasswordEncoder interface {
+	PasswordEncoder
+
+	EncodeContext(ctx context.Context, rawPassword string) (string, error)
+
+	MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error)
+}
+
+// EncodeContext calls encoder.EncodeContext if encoder is a ContextPasswordEncoder,
+// otherwise encoder.Encode unless ctx is already done.
+func EncodeContext(ctx context.Context, encoder PasswordEncoder, rawPassword string) (string, error) {
+	if e, ok := encoder.(ContextPasswordEncoder); ok {
+		return e.EncodeContext(ctx, rawPassword)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return encoder.Encode(rawPassword)
+}
+
+// MatchesContext calls encoder.MatchesContext if encoder is a ContextPasswordEncoder,
+// otherwise encoder.Matches unless ctx is already done.
+func MatchesContext(ctx context.Context, encoder PasswordEncoder, rawPassword string, encodedPassword string) (bool, error) {
+	if e, ok := encoder.(ContextPasswordEncoder); ok {
+		return e.MatchesContext(ctx, rawPassword, encodedPassword)
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return encoder.Matches(rawPassword, encodedPassword), nil
+}
+
+// iterations between checks of ctx in pbkdf2Key
+const pbkdf2CheckInterval = 1024
+
+// pbkdf2Key is golang.org/x/crypto/pbkdf2.Key, aborted when ctx is done.
+func pbkdf2Key(ctx context.Context, password, salt []byte, iter, keyLen int, h func() hash.Hash) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	U := make([]byte, hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		// N.B.: || means concatenation, ^ means XOR
+		// for each block T_i = U_1 ^ U_2 ^ ... ^ U_iter
+		// U_1 = PRF(password, salt || uint(i))
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:4])
+		dk = prf.Sum(dk)
+		T := dk[len(dk)-hashLen:]
+		copy(U, T)
+
+		// U_n = PRF(password, U_(n-1))
+		for n := 2; n <= iter; n++ {
+			if n%pbkdf2CheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+			prf.Reset()
+			prf.Write(U)
+			U = U[:0]
+			U = prf.Sum(U)
+			for x := range U {
+				T[x] ^= U[x]
+			}
+		}
+	}
+	return dk[:keyLen], nil
+}
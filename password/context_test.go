@@ -0,0 +1,122 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// a PasswordEncoder which is not a ContextPasswordEncoder
+type plainPasswordEncoder struct {
+	PasswordEncoder
+}
+
+func TestPbkdf2Key(t *testing.T) {
+	for _, tc := range []struct {
+		iter, keyLen int
+	}{
+		{1, 20}, {2, 32}, {pbkdf2CheckInterval, 32}, {pbkdf2CheckInterval + 1, 64}, {4096, 25},
+	} {
+		for _, h := range []func() hash.Hash{sha1.New, sha256.New, sm3.New} {
+			want := pbkdf2.Key([]byte("password"), []byte("salt"), tc.iter, tc.keyLen, h)
+			got, err := pbkdf2Key(context.Background(), []byte("password"), []byte("salt"), tc.iter, tc.keyLen, h)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+	}
+
+	t.Run("aborts on deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := pbkdf2Key(ctx, []byte("password"), []byte("salt"), 1<<30, 32, sha256.New)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestContextPasswordEncoder(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, encoder := range testEncoders() {
+		t.Run(name, func(t *testing.T) {
+			encodedPassword, err := encoder.EncodeContext(context.Background(), "password")
+			require.NoError(t, err)
+
+			matched, err := encoder.MatchesContext(context.Background(), "password", encodedPassword)
+			assert.NoError(t, err)
+			assert.True(t, matched)
+
+			matched, err = encoder.MatchesContext(context.Background(), "password1", encodedPassword)
+			assert.NoError(t, err)
+			assert.False(t, matched)
+
+			_, err = encoder.EncodeContext(cancelled, "password")
+			assert.ErrorIs(t, err, context.Canceled)
+
+			matched, err = encoder.MatchesContext(cancelled, "password", encodedPassword)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.False(t, matched)
+		})
+	}
+}
+
+func TestEncodeContext(t *testing.T) {
+	encoder := plainPasswordEncoder{NopPasswordEncoder()}
+
+	encodedPassword, err := EncodeContext(context.Background(), encoder, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, "password", encodedPassword)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = EncodeContext(ctx, encoder, "password")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = EncodeContext(context.Background(), plainPasswordEncoder{&errEncodePasswordEncoder{err: errors.New("WTF")}}, "password")
+	assert.Error(t, err)
+}
+
+func TestMatchesContext(t *testing.T) {
+	encoder := plainPasswordEncoder{NopPasswordEncoder()}
+
+	matched, err := MatchesContext(context.Background(), encoder, "password", "password")
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	matched, err = MatchesContext(ctx, encoder, "password", "password")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, matched)
+}
+
+func TestDelegatingPasswordEncoder_MatchesContext(t *testing.T) {
+	encoder := NewDelegatingPasswordEncoder("pbkdf2", map[string]PasswordEncoder{
+		"pbkdf2": NewPbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 1<<30, sha256.Size, sha256.New),
+		"nop":    NopPasswordEncoder(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := encoder.EncodeContext(ctx, "password")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	matched, err := encoder.MatchesContext(context.Background(), "password", "{unknown}password")
+	assert.NoError(t, err)
+	assert.False(t, matched)
+}
@@ -1,6 +1,7 @@
 package password
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -18,17 +19,45 @@ type DelegatingPasswordEncoder struct {
 
 	passwordEncoderForEncode PasswordEncoder
 	idToPasswordEncoder      map[string]PasswordEncoder
+
+	minStrength Strength
 }
 
-// TODO: options / builder
-func NewDelegatingPasswordEncoder(idForEncode string, idToPasswordEncoder map[string]PasswordEncoder) *DelegatingPasswordEncoder {
+var _ ContextPasswordEncoder = (*DelegatingPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*DelegatingPasswordEncoder)(nil)
+
+type DelegatingOption func(e *DelegatingPasswordEncoder)
+
+// WithMinStrength makes UpgradeEncoding return true for passwords encoded by a delegate
+// classified below minStrength, even if it is the delegate for idForEncode.
+// Delegates with StrengthUnknown are not affected.
+func WithMinStrength(minStrength Strength) DelegatingOption {
+	return func(e *DelegatingPasswordEncoder) {
+		e.minStrength = minStrength
+	}
+}
+
+// WithLimit wraps each delegate in its own LimitedPasswordEncoder,
+// so that e.g. a burst of scrypt verifications does not queue bcrypt ones.
+func WithLimit(maxConcurrent int64, opts ...LimitOption) DelegatingOption {
+	return func(e *DelegatingPasswordEncoder) {
+		idToPasswordEncoder := make(map[string]PasswordEncoder, len(e.idToPasswordEncoder))
+		for id, delegate := range e.idToPasswordEncoder {
+			idToPasswordEncoder[id] = NewLimitedPasswordEncoder(delegate, maxConcurrent, opts...)
+		}
+		e.idToPasswordEncoder = idToPasswordEncoder
+		e.passwordEncoderForEncode = idToPasswordEncoder[e.idForEncode]
+	}
+}
+
+func NewDelegatingPasswordEncoder(idForEncode string, idToPasswordEncoder map[string]PasswordEncoder, opts ...DelegatingOption) *DelegatingPasswordEncoder {
 	passwordEncoderForEncode := idToPasswordEncoder[idForEncode]
 
 	if passwordEncoderForEncode == nil {
 		panic(fmt.Errorf("idForEncode %q is not found in idToPasswordEncoder %+v", idForEncode, idToPasswordEncoder))
 	}
 
-	return &DelegatingPasswordEncoder{
+	e := &DelegatingPasswordEncoder{
 		idPrefix:    DefaultIdPrefix,
 		idSuffix:    DefaultIdSuffix,
 		idForEncode: idForEncode,
@@ -37,25 +66,55 @@ func NewDelegatingPasswordEncoder(idForEncode string, idToPasswordEncoder map[st
 
 		idToPasswordEncoder: idToPasswordEncoder,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 func (e *DelegatingPasswordEncoder) Encode(rawPassword string) (string, error) {
-	encodedPassword, err := e.passwordEncoderForEncode.Encode(rawPassword)
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *DelegatingPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *DelegatingPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *DelegatingPasswordEncoder) Matches(rawPassword string, prefixEncodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, prefixEncodedPassword)
+	return matched
+}
+
+func (e *DelegatingPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, prefixEncodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, prefixEncodedPassword)
+}
+
+func (e *DelegatingPasswordEncoder) MatchesBytes(rawPassword []byte, prefixEncodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, prefixEncodedPassword)
+	return matched
+}
+
+func (e *DelegatingPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	encodedPassword, err := encodeBytesContext(ctx, e.passwordEncoderForEncode, rawPassword)
 	if err != nil {
 		return "", err
 	}
 	return e.idPrefix + e.idForEncode + e.idSuffix + encodedPassword, nil
 }
 
-func (e *DelegatingPasswordEncoder) Matches(rawPassword string, prefixEncodedPassword string) bool {
+func (e *DelegatingPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, prefixEncodedPassword string) (bool, error) {
 	id := extractId(prefixEncodedPassword, e.idPrefix, e.idSuffix)
 	delegate, ok := e.idToPasswordEncoder[id]
 	if !ok {
 		// "There is no PasswordEncoder mapped for the id \"" + id + "\""
-		return false
+		return false, nil
 	}
 	encodedPassword := extractEncodedPassword(prefixEncodedPassword, e.idSuffix)
-	return delegate.Matches(rawPassword, encodedPassword)
+	return matchesBytesContext(ctx, delegate, rawPassword, encodedPassword)
 }
 
 func (e *DelegatingPasswordEncoder) UpgradeEncoding(prefixEncodedPassword string) bool {
@@ -71,10 +130,59 @@ func (e *DelegatingPasswordEncoder) UpgradeEncoding(prefixEncodedPassword string
 		return false
 	}
 
+	if strength := StrengthOf(delegate); strength != StrengthUnknown && strength < e.minStrength {
+		return true
+	}
+
 	encodedPassword := extractEncodedPassword(prefixEncodedPassword, e.idSuffix)
 	return delegate.UpgradeEncoding(encodedPassword)
 }
 
+func (e *DelegatingPasswordEncoder) EstimateMemory(prefixEncodedPassword string) int64 {
+	if prefixEncodedPassword == "" {
+		return EstimateMemory(e.passwordEncoderForEncode, "")
+	}
+
+	id := extractId(prefixEncodedPassword, e.idPrefix, e.idSuffix)
+	delegate, ok := e.idToPasswordEncoder[id]
+	if !ok {
+		return 0
+	}
+	encodedPassword := extractEncodedPassword(prefixEncodedPassword, e.idSuffix)
+	return EstimateMemory(delegate, encodedPassword)
+}
+
+// Inspect describes a password encoded by one of the configured delegates
+func (e *DelegatingPasswordEncoder) Inspect(prefixEncodedPassword string) (HashInfo, error) {
+	id := extractId(prefixEncodedPassword, e.idPrefix, e.idSuffix)
+	delegate, ok := e.idToPasswordEncoder[id]
+	if !ok && id != "" {
+		return HashInfo{}, &UnknownIdError{Id: id}
+	}
+	if !ok {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+
+	info, err := inspectWith(delegate, extractEncodedPassword(prefixEncodedPassword, e.idSuffix))
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Id = id
+	info.UpgradeEncoding = e.UpgradeEncoding(prefixEncodedPassword)
+	return info, nil
+}
+
+// LimiterStats returns the stats of delegates limited by WithLimit, by id
+func (e *DelegatingPasswordEncoder) LimiterStats() map[string]LimiterStats {
+	stats := make(map[string]LimiterStats)
+	for id, delegate := range e.idToPasswordEncoder {
+		if limited, ok := delegate.(*LimitedPasswordEncoder); ok {
+			stats[id] = limited.Stats()
+		}
+	}
+	return stats
+}
+
 func extractId(prefixEncodedPassword string, idPrefix, idSuffix string) string {
 	if prefixEncodedPassword == "" {
 		return ""
@@ -153,6 +153,40 @@ func TestDelegatingPasswordEncoder_UpgradeEncoding(t *testing.T) {
 	})
 }
 
+func TestDelegatingPasswordEncoder_UpgradeEncoding_WithMinStrength(t *testing.T) {
+	legacy := &classifiedPasswordEncoder{PasswordEncoder: NewBCryptPasswordEncoder(bcrypt.MinCost), strength: StrengthLegacy}
+	unknown := &classifiedPasswordEncoder{PasswordEncoder: NewBCryptPasswordEncoder(bcrypt.MinCost), strength: StrengthUnknown}
+
+	idToPasswordEncoder := map[string]PasswordEncoder{
+		"legacy":  legacy,
+		"unknown": unknown,
+	}
+
+	t.Run("below minStrength", func(t *testing.T) {
+		delegatingEncoder := NewDelegatingPasswordEncoder("legacy", idToPasswordEncoder)
+		encodedPassword, err := delegatingEncoder.Encode("password")
+		require.NoError(t, err)
+		assert.Equal(t, false, delegatingEncoder.UpgradeEncoding(encodedPassword))
+
+		delegatingEncoder = NewDelegatingPasswordEncoder("legacy", idToPasswordEncoder, WithMinStrength(StrengthStrong))
+		assert.Equal(t, true, delegatingEncoder.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("at minStrength", func(t *testing.T) {
+		delegatingEncoder := NewDelegatingPasswordEncoder("legacy", idToPasswordEncoder, WithMinStrength(StrengthLegacy))
+		encodedPassword, err := delegatingEncoder.Encode("password")
+		require.NoError(t, err)
+		assert.Equal(t, false, delegatingEncoder.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("unknown strength", func(t *testing.T) {
+		delegatingEncoder := NewDelegatingPasswordEncoder("unknown", idToPasswordEncoder, WithMinStrength(StrengthStrong))
+		encodedPassword, err := delegatingEncoder.Encode("password")
+		require.NoError(t, err)
+		assert.Equal(t, false, delegatingEncoder.UpgradeEncoding(encodedPassword))
+	})
+}
+
 func Test_extractId(t *testing.T) {
 	type args struct {
 		prefixEncodedPassword string
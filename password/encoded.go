@@ -0,0 +1,70 @@
+package password
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+const redacted = "[REDACTED]"
+
+// EncodedPassword is the output of PasswordEncoder.Encode, for struct fields and database columns.
+//
+// It is stored as is by database/sql, but redacted by fmt, encoding/json and log/slog (Go 1.21+),
+// e.g. "{bcrypt}[REDACTED]", so that it does not leak into logs and responses.
+// Use string(encodedPassword) where the encoded password itself is needed.
+type EncodedPassword string
+
+var (
+	_ sql.Scanner    = (*EncodedPassword)(nil)
+	_ driver.Valuer  = EncodedPassword("")
+	_ fmt.Stringer   = EncodedPassword("")
+	_ fmt.GoStringer = EncodedPassword("")
+	_ json.Marshaler = EncodedPassword("")
+)
+
+// Scan implements sql.Scanner, NULL scans to ""
+func (p *EncodedPassword) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*p = ""
+	case string:
+		*p = EncodedPassword(v)
+	case []byte:
+		*p = EncodedPassword(v)
+	default:
+		return fmt.Errorf("password: cannot scan %T into EncodedPassword", src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer
+func (p EncodedPassword) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+// String returns the "{id}" prefix, if any, followed by "[REDACTED]"
+func (p EncodedPassword) String() string {
+	if id, _, ok := cutId(string(p)); ok {
+		return DefaultIdPrefix + id + DefaultIdSuffix + redacted
+	}
+	return redacted
+}
+
+func (p EncodedPassword) GoString() string {
+	return fmt.Sprintf("password.EncodedPassword(%q)", p.String())
+}
+
+// MarshalJSON returns String as a JSON string
+func (p EncodedPassword) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p EncodedPassword) Matches(encoder PasswordEncoder, rawPassword string) bool {
+	return encoder.Matches(rawPassword, string(p))
+}
+
+func (p EncodedPassword) UpgradeEncoding(encoder PasswordEncoder) bool {
+	return encoder.UpgradeEncoding(string(p))
+}
@@ -0,0 +1,12 @@
+//go:build go1.21
+
+package password
+
+import "log/slog"
+
+var _ slog.LogValuer = EncodedPassword("")
+
+// LogValue implements slog.LogValuer with String
+func (p EncodedPassword) LogValue() slog.Value {
+	return slog.StringValue(p.String())
+}
@@ -0,0 +1,23 @@
+//go:build go1.21
+
+package password
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodedPassword_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("login", "password", springBCryptPassword)
+	assert.Contains(t, buf.String(), `"password":"{bcrypt}[REDACTED]"`)
+
+	buf.Reset()
+	logger = slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("login", slog.Any("password", springBCryptPassword))
+	assert.Contains(t, buf.String(), `password={bcrypt}[REDACTED]`)
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	_ "modernc.org/sqlite"
+)
+
+const springBCryptPassword = EncodedPassword("{bcrypt}$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG")
+
+func TestEncodedPassword_String(t *testing.T) {
+	assert.Equal(t, "{bcrypt}[REDACTED]", springBCryptPassword.String())
+	assert.Equal(t, "[REDACTED]", EncodedPassword("$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG").String())
+	assert.Equal(t, "[REDACTED]", EncodedPassword("").String())
+
+	for _, format := range []string{"%v", "%s", "%q", "%x", "%+v", "%#v"} {
+		s := fmt.Sprintf(format, struct{ Password EncodedPassword }{springBCryptPassword})
+		assert.NotContains(t, s, "$2a$", format)
+		assert.NotContains(t, s, "2432", format) // hex
+	}
+}
+
+func TestEncodedPassword_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(struct {
+		Password EncodedPassword `json:"password"`
+	}{springBCryptPassword})
+	require.NoError(t, err)
+	assert.Equal(t, `{"password":"{bcrypt}[REDACTED]"}`, string(b))
+}
+
+func TestEncodedPassword_Matches(t *testing.T) {
+	encoder := NewDelegatingPasswordEncoder("bcrypt", map[string]PasswordEncoder{
+		"bcrypt": NewBCryptPasswordEncoder(bcrypt.DefaultCost),
+	})
+	assert.True(t, springBCryptPassword.Matches(encoder, "password"))
+	assert.False(t, springBCryptPassword.Matches(encoder, "password1"))
+	assert.False(t, springBCryptPassword.UpgradeEncoding(encoder))
+	assert.True(t, EncodedPassword("$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG").UpgradeEncoding(encoder))
+}
+
+func TestEncodedPassword_Scan(t *testing.T) {
+	var p EncodedPassword
+	assert.NoError(t, p.Scan([]byte(springBCryptPassword)))
+	assert.Equal(t, springBCryptPassword, p)
+	assert.NoError(t, p.Scan(nil))
+	assert.Equal(t, EncodedPassword(""), p)
+	assert.Error(t, p.Scan(42))
+}
+
+func TestEncodedPassword_sql(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1) // each connection has its own in-memory database
+
+	_, err = db.Exec("CREATE TABLE users (name TEXT PRIMARY KEY, password TEXT)")
+	require.NoError(t, err)
+
+	encoder := NewBCryptPasswordEncoder(bcrypt.MinCost)
+	encodedPassword, err := encoder.Encode("password")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO users (name, password) VALUES (?, ?)", "alice", EncodedPassword(encodedPassword))
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users (name, password) VALUES (?, ?)", "bob", nil)
+	require.NoError(t, err)
+
+	var p EncodedPassword
+	require.NoError(t, db.QueryRow("SELECT password FROM users WHERE name = ?", "alice").Scan(&p))
+	assert.Equal(t, EncodedPassword(encodedPassword), p)
+	assert.True(t, p.Matches(encoder, "password"))
+
+	require.NoError(t, db.QueryRow("SELECT password FROM users WHERE name = ?", "bob").Scan(&p))
+	assert.Equal(t, EncodedPassword(""), p)
+}
@@ -0,0 +1,191 @@
+package password
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/emmansun/gmsm/sm4"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// EncryptingPasswordEncoder encrypts the output of another PasswordEncoder
+// (e.g. a bcrypt string) with an AEAD cipher such as AES-256-GCM or SM4-GCM.
+//
+// The encoded password is "{keyId}" + base64(nonce + ciphertext), the key id is
+// also bound to the ciphertext as additional data. Hashes encrypted with a
+// retired key keep verifying and UpgradeEncoding returns true for them.
+type EncryptingPasswordEncoder struct {
+	idPrefix    string
+	idSuffix    string
+	idForEncode string
+
+	delegate      PasswordEncoder
+	idToAead      map[string]cipher.AEAD
+	aeadForEncode cipher.AEAD
+	nonceGen      keygen.BytesKeyGenerator
+}
+
+var _ ContextPasswordEncoder = (*EncryptingPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*EncryptingPasswordEncoder)(nil)
+
+func NewEncryptingPasswordEncoder(delegate PasswordEncoder, idForEncode string, idToAead map[string]cipher.AEAD) *EncryptingPasswordEncoder {
+	aeadForEncode := idToAead[idForEncode]
+
+	if aeadForEncode == nil {
+		panic(fmt.Errorf("idForEncode %q is not found in idToAead", idForEncode))
+	}
+
+	return &EncryptingPasswordEncoder{
+		idPrefix:    DefaultIdPrefix,
+		idSuffix:    DefaultIdSuffix,
+		idForEncode: idForEncode,
+
+		delegate:      delegate,
+		idToAead:      idToAead,
+		aeadForEncode: aeadForEncode,
+		nonceGen:      keygen.NewSecureRandomBytesKeyGenerator(aeadForEncode.NonceSize()),
+	}
+}
+
+// NewAesGcm returns AES-GCM, key must be 16, 24 or 32 bytes (AES-256 for 32 bytes)
+func NewAesGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewSm4Gcm returns SM4-GCM, key must be 16 bytes
+func NewSm4Gcm(key []byte) (cipher.AEAD, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (e *EncryptingPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *EncryptingPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *EncryptingPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *EncryptingPasswordEncoder) Matches(rawPassword string, encryptedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encryptedPassword)
+	return matched
+}
+
+func (e *EncryptingPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encryptedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encryptedPassword)
+}
+
+func (e *EncryptingPasswordEncoder) MatchesBytes(rawPassword []byte, encryptedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encryptedPassword)
+	return matched
+}
+
+func (e *EncryptingPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	encodedPassword, err := encodeBytesContext(ctx, e.delegate, rawPassword)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := e.nonceGen.GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != e.aeadForEncode.NonceSize() {
+		return "", fmt.Errorf("nonce length %d, want %d", len(nonce), e.aeadForEncode.NonceSize())
+	}
+
+	sealed := e.aeadForEncode.Seal(nonce, nonce, []byte(encodedPassword), []byte(e.idForEncode))
+	return e.idPrefix + e.idForEncode + e.idSuffix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (e *EncryptingPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encryptedPassword string) (bool, error) {
+	encodedPassword, ok := e.decrypt(encryptedPassword)
+	if !ok {
+		return false, nil
+	}
+	return matchesBytesContext(ctx, e.delegate, rawPassword, encodedPassword)
+}
+
+func (e *EncryptingPasswordEncoder) UpgradeEncoding(encryptedPassword string) bool {
+	id := extractId(encryptedPassword, e.idPrefix, e.idSuffix)
+
+	if e.idForEncode != id {
+		return true
+	}
+
+	encodedPassword, ok := e.decrypt(encryptedPassword)
+	if !ok {
+		return false
+	}
+	return e.delegate.UpgradeEncoding(encodedPassword)
+}
+
+// the Strength of the delegate
+func (e *EncryptingPasswordEncoder) Strength() Strength {
+	return StrengthOf(e.delegate)
+}
+
+func (e *EncryptingPasswordEncoder) EstimateMemory(encryptedPassword string) int64 {
+	if encryptedPassword == "" {
+		return EstimateMemory(e.delegate, "")
+	}
+	encodedPassword, ok := e.decrypt(encryptedPassword)
+	if !ok {
+		return 0
+	}
+	return EstimateMemory(e.delegate, encodedPassword)
+}
+
+// Inspect decrypts the encoded password of the delegate
+func (e *EncryptingPasswordEncoder) Inspect(encryptedPassword string) (HashInfo, error) {
+	encodedPassword, ok := e.decrypt(encryptedPassword)
+	if !ok {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+
+	info, err := inspectWith(e.delegate, encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.KeyId = extractId(encryptedPassword, e.idPrefix, e.idSuffix)
+	info.UpgradeEncoding = e.UpgradeEncoding(encryptedPassword)
+	return info, nil
+}
+
+func (e *EncryptingPasswordEncoder) decrypt(encryptedPassword string) (string, bool) {
+	id := extractId(encryptedPassword, e.idPrefix, e.idSuffix)
+	aead, ok := e.idToAead[id]
+	if !ok {
+		return "", false
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(extractEncodedPassword(encryptedPassword, e.idSuffix))
+	if err != nil {
+		return "", false
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", false
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	encodedPassword, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", false
+	}
+	return string(encodedPassword), true
+}
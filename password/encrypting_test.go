@@ -0,0 +1,138 @@
+package password
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen/keygentest"
+)
+
+func mustAead(aead cipher.AEAD, err error) cipher.AEAD {
+	if err != nil {
+		panic(err)
+	}
+	return aead
+}
+
+func TestNewEncryptingPasswordEncoder(t *testing.T) {
+	t.Run("panics idForEncode not found", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewEncryptingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), "k1", map[string]cipher.AEAD{})
+		})
+	})
+}
+
+func TestNewAesGcm(t *testing.T) {
+	_, err := NewAesGcm(make([]byte, 32))
+	assert.NoError(t, err)
+
+	_, err = NewAesGcm(make([]byte, 7))
+	assert.Error(t, err)
+}
+
+func TestNewSm4Gcm(t *testing.T) {
+	_, err := NewSm4Gcm(make([]byte, 16))
+	assert.NoError(t, err)
+
+	_, err = NewSm4Gcm(make([]byte, 32))
+	assert.Error(t, err)
+}
+
+func TestEncryptingPasswordEncoder_Matches(t *testing.T) {
+	aes256 := mustAead(NewAesGcm(bytes.Repeat([]byte{1}, 32)))
+	sm4 := mustAead(NewSm4Gcm(bytes.Repeat([]byte{2}, 16)))
+
+	for id, aead := range map[string]cipher.AEAD{"aes": aes256, "sm4": sm4} {
+		t.Run(id, func(t *testing.T) {
+			delegate := NewBCryptPasswordEncoder(bcrypt.MinCost)
+			encoder := NewEncryptingPasswordEncoder(delegate, id, map[string]cipher.AEAD{id: aead})
+
+			rawPassword := "password"
+			encryptedPassword, err := encoder.Encode(rawPassword)
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encryptedPassword, "{"+id+"}"))
+			assert.False(t, strings.Contains(encryptedPassword, "$2a$"))
+			assert.True(t, encoder.Matches(rawPassword, encryptedPassword))
+			assert.False(t, encoder.Matches(rawPassword+"a", encryptedPassword))
+
+			assert.False(t, encoder.Matches(rawPassword, "{other}"+extractEncodedPassword(encryptedPassword, DefaultIdSuffix)))
+			assert.False(t, encoder.Matches(rawPassword, "{"+id+"}_"))    // invalid base64
+			assert.False(t, encoder.Matches(rawPassword, "{"+id+"}AAAA")) // too short
+			assert.False(t, encoder.Matches(rawPassword, ""))
+
+			// tampered ciphertext
+			sealed, err := base64.StdEncoding.DecodeString(extractEncodedPassword(encryptedPassword, DefaultIdSuffix))
+			require.NoError(t, err)
+			sealed[len(sealed)-1] ^= 1
+			assert.False(t, encoder.Matches(rawPassword, "{"+id+"}"+base64.StdEncoding.EncodeToString(sealed)))
+		})
+	}
+
+	t.Run("key id is authenticated", func(t *testing.T) {
+		encoder := NewEncryptingPasswordEncoder(NopPasswordEncoder(), "k1", map[string]cipher.AEAD{
+			"k1": aes256,
+			"k2": aes256,
+		})
+
+		encryptedPassword, err := encoder.Encode("password")
+		require.NoError(t, err)
+
+		assert.True(t, encoder.Matches("password", encryptedPassword))
+		assert.False(t, encoder.Matches("password", "{k2}"+extractEncodedPassword(encryptedPassword, DefaultIdSuffix)))
+	})
+}
+
+func TestEncryptingPasswordEncoder_Encode(t *testing.T) {
+	aead := mustAead(NewAesGcm(make([]byte, 32)))
+
+	t.Run("err delegate", func(t *testing.T) {
+		encoder := NewEncryptingPasswordEncoder(&errEncodePasswordEncoder{err: errors.New("WTF")}, "k1", map[string]cipher.AEAD{"k1": aead})
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
+
+	t.Run("err nonceGen", func(t *testing.T) {
+		encoder := NewEncryptingPasswordEncoder(NopPasswordEncoder(), "k1", map[string]cipher.AEAD{"k1": aead})
+		encoder.nonceGen = keygentest.ErrBytesKeyGenerator(errors.New("oops"), aead.NonceSize())
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptingPasswordEncoder_UpgradeEncoding(t *testing.T) {
+	k1 := mustAead(NewAesGcm(bytes.Repeat([]byte{1}, 32)))
+	k2 := mustAead(NewSm4Gcm(bytes.Repeat([]byte{2}, 16)))
+
+	delegate := NewBCryptPasswordEncoder(bcrypt.MinCost)
+	old := NewEncryptingPasswordEncoder(delegate, "k1", map[string]cipher.AEAD{"k1": k1})
+	rotated := NewEncryptingPasswordEncoder(delegate, "k2", map[string]cipher.AEAD{"k1": k1, "k2": k2})
+
+	encryptedPassword, err := old.Encode("password")
+	require.NoError(t, err)
+
+	t.Run("current key", func(t *testing.T) {
+		assert.Equal(t, false, old.UpgradeEncoding(encryptedPassword))
+	})
+
+	t.Run("retired key", func(t *testing.T) {
+		assert.True(t, rotated.Matches("password", encryptedPassword))
+		assert.Equal(t, true, rotated.UpgradeEncoding(encryptedPassword))
+
+		reencryptedPassword, err := rotated.Encode("password")
+		require.NoError(t, err)
+		assert.Equal(t, false, rotated.UpgradeEncoding(reencryptedPassword))
+	})
+
+	t.Run("undecryptable", func(t *testing.T) {
+		assert.Equal(t, false, old.UpgradeEncoding("{k1}_"))
+	})
+}
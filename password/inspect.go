@@ -0,0 +1,282 @@
+package password
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBCrypt       = "bcrypt"
+	AlgorithmBCryptSha384 = "bcrypt-sha384"
+	AlgorithmSCrypt       = "scrypt"
+	AlgorithmPbkdf2       = "pbkdf2"
+	AlgorithmPbkdf2Sm3    = "pbkdf2-sm3"
+	AlgorithmArgon2id     = "argon2id"
+	AlgorithmArgon2i      = "argon2i"
+	AlgorithmSha256       = "sha256"
+	AlgorithmSm3          = "sm3"
+	AlgorithmNoop         = "noop"
+)
+
+var ErrUnrecognizedEncoding = errors.New("password: unrecognized encoded password")
+
+// UnknownIdError is returned by DelegatingPasswordEncoder.Inspect for an "{id}" without PasswordEncoder,
+// errors.Is(err, ErrUnrecognizedEncoding) is true. UpgradeEncoding is true for such encoded passwords.
+type UnknownIdError struct {
+	Id string
+}
+
+func (e *UnknownIdError) Error() string {
+	return fmt.Sprintf("password: no PasswordEncoder for id %q", e.Id)
+}
+
+func (e *UnknownIdError) Unwrap() error {
+	return ErrUnrecognizedEncoding
+}
+
+// HashInfo describes an encoded password, as far as it can be told without the raw password.
+type HashInfo struct {
+	Id         string   `json:"id,omitempty"`         // "{id}" prefix of DelegatingPasswordEncoder
+	KeyId      string   `json:"keyId,omitempty"`      // "{keyId}" prefix of PepperPasswordEncoder or EncryptingPasswordEncoder
+	Algorithm  string   `json:"algorithm,omitempty"`  // one of the Algorithm constants, "" if ambiguous
+	Candidates []string `json:"candidates,omitempty"` // possible algorithms if ambiguous, e.g. hex(salt + digest)
+	Strength   Strength `json:"strength"`
+
+	Version     string `json:"version,omitempty"`     // bcrypt "2a", "2b"...; argon2 "19"
+	Cost        int    `json:"cost,omitempty"`        // bcrypt cost; scrypt N
+	BlockSize   int    `json:"blockSize,omitempty"`   // scrypt r
+	Memory      int    `json:"memory,omitempty"`      // argon2 m in KiB
+	Iterations  int    `json:"iterations,omitempty"`  // pbkdf2, pbkdf2-sm3; argon2 t
+	Parallelism int    `json:"parallelism,omitempty"` // scrypt p; argon2 p
+	SaltLength  int    `json:"saltLength,omitempty"`  // bytes
+	KeyLength   int    `json:"keyLength,omitempty"`   // bytes
+
+	Onion       bool   `json:"onion,omitempty"`       // a legacy digest wrapped by OnionPasswordEncoder in Algorithm
+	Preparation string `json:"preparation,omitempty"` // Preparation id of NormalizingPasswordEncoder
+
+	// the result of UpgradeEncoding if inspected by an encoder,
+	// otherwise true if Strength is known and below StrengthStrong
+	UpgradeEncoding bool `json:"upgradeEncoding"`
+}
+
+// Inspector is implemented by PasswordEncoders that describe their own encoded passwords,
+// with parameters they do not record, e.g. pbkdf2 iterations, and UpgradeEncoding.
+type Inspector interface {
+	Inspect(encodedPassword string) (HashInfo, error)
+}
+
+// the algorithms of the ids in DelegatingPasswordEncoder examples,
+// resolving formats that are ambiguous without an id
+var wellKnownIds = map[string]string{
+	"bcrypt": AlgorithmBCrypt,
+	"scrypt": AlgorithmSCrypt,
+	"pbkdf2": AlgorithmPbkdf2,
+	"sha256": AlgorithmSha256,
+	"sm3":    AlgorithmSm3,
+	"noop":   AlgorithmNoop,
+}
+
+var hexCandidates = []string{AlgorithmPbkdf2, AlgorithmSha256, AlgorithmSm3}
+
+var algorithmStrengths = map[string]Strength{
+	AlgorithmBCrypt:       StrengthStrong,
+	AlgorithmBCryptSha384: StrengthStrong,
+	AlgorithmSCrypt:       StrengthStrong,
+	AlgorithmPbkdf2:       StrengthStrong,
+	AlgorithmPbkdf2Sm3:    StrengthStrong,
+	AlgorithmArgon2id:     StrengthStrong,
+	AlgorithmArgon2i:      StrengthStrong,
+	AlgorithmSha256:       StrengthLegacy,
+	AlgorithmSm3:          StrengthLegacy,
+	AlgorithmNoop:         StrengthInsecure,
+}
+
+// Inspect recognizes the formats of the encoders of this package, with an optional "{id}" prefix.
+//
+// Hex formats (pbkdf2, sha256, sm3) are ambiguous unless the id is one of
+// "pbkdf2", "sha256" or "sm3", their lengths assume a 32 bytes key.
+// Plaintext is only recognized with the "noop" id.
+// Onion encoded passwords are described by their outer encoded password, with Onion set.
+func Inspect(encodedPassword string) (HashInfo, error) {
+	info, err := inspect(encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Strength = algorithmStrengths[info.Algorithm]
+	info.UpgradeEncoding = info.Onion || info.Strength != StrengthUnknown && info.Strength < StrengthStrong
+	return info, nil
+}
+
+func inspect(encodedPassword string) (HashInfo, error) {
+	id, encodedPassword, ok := cutId(encodedPassword)
+	if !ok {
+		return inspectEncoded(encodedPassword, "")
+	}
+
+	// "{id}{preparation}..."
+	preparation := ""
+	if preparationId, preparedEncodedPassword, ok := cutId(encodedPassword); ok {
+		if _, ok := preparations[preparationId]; ok {
+			preparation, encodedPassword = preparationId, preparedEncodedPassword
+		}
+	}
+
+	// "{id}{keyId}..."
+	keyId, keyEncodedPassword, ok := cutId(encodedPassword)
+	if ok {
+		info, err := inspectEncoded(keyEncodedPassword, wellKnownIds[id])
+		info.Id, info.KeyId, info.Preparation = id, keyId, preparation
+		return info, err
+	}
+
+	info, err := inspectEncoded(encodedPassword, wellKnownIds[id])
+	info.Id, info.Preparation = id, preparation
+	return info, err
+}
+
+// inspectEncoded inspects an encoded password without prefix, hint is the algorithm of a well-known id
+func inspectEncoded(encodedPassword string, hint string) (HashInfo, error) {
+	switch {
+	case hint == AlgorithmNoop:
+		return HashInfo{Algorithm: AlgorithmNoop}, nil
+	case strings.HasPrefix(encodedPassword, "$2") && len(encodedPassword) == bcryptLen:
+		return inspectBCrypt(encodedPassword)
+	case strings.HasPrefix(encodedPassword, bcryptSha384Prefix+"$2"):
+		return inspectBCrypt(encodedPassword)
+	case strings.HasPrefix(encodedPassword, "$argon2"):
+		return inspectArgon2(encodedPassword)
+	case strings.HasPrefix(encodedPassword, sm3Pbkdf2Prefix):
+		return inspectSm3Pbkdf2(encodedPassword)
+	case strings.HasPrefix(encodedPassword, "$"):
+		return inspectSCrypt(encodedPassword)
+	}
+
+	if _, outerEncodedPassword, ok := decodeOnion(encodedPassword); ok {
+		info, err := inspectEncoded(outerEncodedPassword, "")
+		if err != nil {
+			return HashInfo{}, err
+		}
+		info.Onion = true
+		return info, nil
+	}
+
+	info, err := inspectHex(encodedPassword, 32)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	for _, candidate := range hexCandidates {
+		if hint == candidate {
+			info.Algorithm = hint
+			return info, nil
+		}
+	}
+	info.Candidates = hexCandidates
+	return info, nil
+}
+
+func cutId(prefixEncodedPassword string) (id string, encodedPassword string, ok bool) {
+	if !strings.HasPrefix(prefixEncodedPassword, DefaultIdPrefix) || !strings.Contains(prefixEncodedPassword, DefaultIdSuffix) {
+		return "", prefixEncodedPassword, false
+	}
+	return extractId(prefixEncodedPassword, DefaultIdPrefix, DefaultIdSuffix), extractEncodedPassword(prefixEncodedPassword, DefaultIdSuffix), true
+}
+
+// inspectWith returns encoder.Inspect if encoder is an Inspector, otherwise Inspect with the Strength and UpgradeEncoding of encoder
+func inspectWith(encoder PasswordEncoder, encodedPassword string) (HashInfo, error) {
+	if i, ok := encoder.(Inspector); ok {
+		return i.Inspect(encodedPassword)
+	}
+	info, err := inspect(encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Strength = StrengthOf(encoder)
+	info.UpgradeEncoding = encoder.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+// "$2a$10$" + 22 chars salt + 31 chars hash in bcrypt's base64
+const bcryptLen = 60
+
+// also "$sha384" + a bcrypt hash, see LongPasswordPreHash
+func inspectBCrypt(encodedPassword string) (HashInfo, error) {
+	algorithm := AlgorithmBCrypt
+	if strings.HasPrefix(encodedPassword, bcryptSha384Prefix) {
+		algorithm = AlgorithmBCryptSha384
+		encodedPassword = strings.TrimPrefix(encodedPassword, bcryptSha384Prefix)
+	}
+	if len(encodedPassword) != bcryptLen {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+	cost, err := bcrypt.Cost([]byte(encodedPassword))
+	if err != nil {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+	return HashInfo{
+		Algorithm:  algorithm,
+		Version:    strings.Split(encodedPassword, "$")[1],
+		Cost:       cost,
+		SaltLength: 16,
+		KeyLength:  23,
+	}, nil
+}
+
+func inspectSCrypt(encodedPassword string) (HashInfo, error) {
+	cpuCost, memoryCost, parallelization, salt, derived, ok := (&SCryptPasswordEncoder{}).decode(encodedPassword)
+	if !ok {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+	return HashInfo{
+		Algorithm:   AlgorithmSCrypt,
+		Cost:        cpuCost,
+		BlockSize:   memoryCost,
+		Parallelism: parallelization,
+		SaltLength:  len(salt),
+		KeyLength:   len(derived),
+	}, nil
+}
+
+func inspectArgon2(encodedPassword string) (HashInfo, error) {
+	params, salt, hash, ok := (&Argon2PasswordEncoder{}).decode(encodedPassword)
+	if !ok {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+	return HashInfo{
+		Algorithm:   params.variant,
+		Version:     "19",
+		Memory:      params.memory,
+		Iterations:  params.iterations,
+		Parallelism: params.parallelism,
+		SaltLength:  len(salt),
+		KeyLength:   len(hash),
+	}, nil
+}
+
+func inspectSm3Pbkdf2(encodedPassword string) (HashInfo, error) {
+	iter, salt, key, ok := (&Sm3Pbkdf2PasswordEncoder{}).decode(encodedPassword)
+	if !ok {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+	return HashInfo{
+		Algorithm:  AlgorithmPbkdf2Sm3,
+		Iterations: iter,
+		SaltLength: len(salt),
+		KeyLength:  len(key),
+	}, nil
+}
+
+// hex(salt + key)
+func inspectHex(encodedPassword string, keyLen int) (HashInfo, error) {
+	n := hex.DecodedLen(len(encodedPassword))
+	if _, err := hex.DecodeString(encodedPassword); err != nil || n < keyLen {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+	return HashInfo{
+		SaltLength: n - keyLen,
+		KeyLength:  keyLen,
+	}, nil
+}
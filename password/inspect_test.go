@@ -0,0 +1,220 @@
+package password
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// Spring Security's sample encoded passwords of "password"
+const (
+	springBCrypt = "$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG"
+	springPbkdf2 = "5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8dc"
+	springSCrypt = "$e0801$8bWJaSu2IKSn9Z9kM+TPXfOc/9bdYSrN1oD9qfVThWEwdRTnO7re7Ei+fUZRJ68k9lTyuTeUp4of4g24hHnazw==$OAOec05+bXxvuu/1qZ6NUR+xQYvYv7BeL1QxwRpY5Pc="
+	springSha256 = "97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name            string
+		encodedPassword string
+		want            HashInfo
+	}{
+		{
+			name:            "bcrypt",
+			encodedPassword: springBCrypt,
+			want:            HashInfo{Algorithm: AlgorithmBCrypt, Strength: StrengthStrong, Version: "2a", Cost: 10, SaltLength: 16, KeyLength: 23},
+		},
+		{
+			name:            "{bcrypt}",
+			encodedPassword: "{bcrypt}" + springBCrypt,
+			want:            HashInfo{Id: "bcrypt", Algorithm: AlgorithmBCrypt, Strength: StrengthStrong, Version: "2a", Cost: 10, SaltLength: 16, KeyLength: 23},
+		},
+		{
+			name:            "scrypt",
+			encodedPassword: "{scrypt}" + springSCrypt,
+			want:            HashInfo{Id: "scrypt", Algorithm: AlgorithmSCrypt, Strength: StrengthStrong, Cost: 16384, BlockSize: 8, Parallelism: 1, SaltLength: 64, KeyLength: 32},
+		},
+		{
+			name:            "argon2id",
+			encodedPassword: "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+			want:            HashInfo{Algorithm: AlgorithmArgon2id, Strength: StrengthStrong, Version: "19", Memory: 65536, Iterations: 2, Parallelism: 1, SaltLength: 8, KeyLength: 32},
+		},
+		{
+			name:            "pbkdf2-sm3",
+			encodedPassword: "$pbkdf2-sm3$i=1000$AAECAwQFBgcICQoLDA0ODw$MEwyS1xzWE068inyJLnAwqfoVBrZnpdtRhghJfOEPw0",
+			want:            HashInfo{Algorithm: AlgorithmPbkdf2Sm3, Strength: StrengthStrong, Iterations: 1000, SaltLength: 16, KeyLength: 32},
+		},
+		{
+			name:            "{pbkdf2}",
+			encodedPassword: "{pbkdf2}" + springPbkdf2,
+			want:            HashInfo{Id: "pbkdf2", Algorithm: AlgorithmPbkdf2, Strength: StrengthStrong, SaltLength: 8, KeyLength: 32},
+		},
+		{
+			name:            "{sha256}",
+			encodedPassword: "{sha256}" + springSha256,
+			want:            HashInfo{Id: "sha256", Algorithm: AlgorithmSha256, Strength: StrengthLegacy, SaltLength: 8, KeyLength: 32, UpgradeEncoding: true},
+		},
+		{
+			name:            "ambiguous hex",
+			encodedPassword: springSha256,
+			want:            HashInfo{Candidates: []string{AlgorithmPbkdf2, AlgorithmSha256, AlgorithmSm3}, SaltLength: 8, KeyLength: 32},
+		},
+		{
+			name:            "{custom} hex",
+			encodedPassword: "{custom}" + springSha256,
+			want:            HashInfo{Id: "custom", Candidates: []string{AlgorithmPbkdf2, AlgorithmSha256, AlgorithmSm3}, SaltLength: 8, KeyLength: 32},
+		},
+		{
+			name:            "{noop}",
+			encodedPassword: "{noop}password",
+			want:            HashInfo{Id: "noop", Algorithm: AlgorithmNoop, Strength: StrengthInsecure, UpgradeEncoding: true},
+		},
+		{
+			name:            "{id}{keyId}",
+			encodedPassword: "{bcrypt}{k1}" + springBCrypt,
+			want:            HashInfo{Id: "bcrypt", KeyId: "k1", Algorithm: AlgorithmBCrypt, Strength: StrengthStrong, Version: "2a", Cost: 10, SaltLength: 16, KeyLength: 23},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Inspect(tt.encodedPassword)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("unrecognized", func(t *testing.T) {
+		for _, encodedPassword := range []string{
+			"",
+			"password",
+			"{bcrypt}",
+			"{noop password",
+			"$2a$10$tooshort",
+			"$2a$99$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG",
+			"$argon2d$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+			"$pbkdf2-sm3$i=0$AAECAwQFBgcICQoLDA0ODw$MEwyS1xzWE068inyJLnAwqfoVBrZnpdtRhghJfOEPw0",
+			"$e0801$_$_",
+			"97cde38028ad898e", // shorter than a digest
+		} {
+			_, err := Inspect(encodedPassword)
+			assert.ErrorIs(t, err, ErrUnrecognizedEncoding, encodedPassword)
+		}
+	})
+}
+
+func TestDelegatingPasswordEncoder_Inspect(t *testing.T) {
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(8)
+	encoder := NewDelegatingPasswordEncoder("bcrypt", map[string]PasswordEncoder{
+		"bcrypt":  NewBCryptPasswordEncoder(bcrypt.DefaultCost),
+		"pbkdf2":  NewPbkdf2PasswordEncoder(saltGen, 185000, sha256.Size, sha256.New),
+		"scrypt":  DefaultSCryptPasswordEncoder(),
+		"legacy":  NewSha256PasswordEncoder(saltGen),
+		"custom":  plainPasswordEncoder{NewBCryptPasswordEncoder(bcrypt.DefaultCost)},
+		"limited": NewLimitedPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.DefaultCost), 1),
+	})
+
+	t.Run("bcrypt", func(t *testing.T) {
+		info, err := encoder.Inspect("{bcrypt}" + springBCrypt)
+		require.NoError(t, err)
+		assert.Equal(t, HashInfo{Id: "bcrypt", Algorithm: AlgorithmBCrypt, Strength: StrengthStrong, Version: "2a", Cost: 10, SaltLength: 16, KeyLength: 23}, info)
+	})
+
+	t.Run("pbkdf2 configured iterations", func(t *testing.T) {
+		info, err := encoder.Inspect("{pbkdf2}" + springPbkdf2)
+		require.NoError(t, err)
+		assert.Equal(t, AlgorithmPbkdf2, info.Algorithm)
+		assert.Equal(t, 185000, info.Iterations)
+		assert.Equal(t, 8, info.SaltLength)
+		assert.Equal(t, true, info.UpgradeEncoding) // not the idForEncode
+	})
+
+	t.Run("legacy id resolves hex", func(t *testing.T) {
+		info, err := encoder.Inspect("{legacy}" + springSha256)
+		require.NoError(t, err)
+		assert.Equal(t, "legacy", info.Id)
+		assert.Equal(t, AlgorithmSha256, info.Algorithm)
+		assert.Nil(t, info.Candidates)
+		assert.Equal(t, StrengthLegacy, info.Strength)
+	})
+
+	t.Run("not an Inspector", func(t *testing.T) {
+		info, err := encoder.Inspect("{custom}" + springBCrypt)
+		require.NoError(t, err)
+		assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+		assert.Equal(t, StrengthUnknown, info.Strength)
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		info, err := encoder.Inspect("{limited}" + springBCrypt)
+		require.NoError(t, err)
+		assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		_, err := encoder.Inspect("{unknown}" + springBCrypt)
+		assert.ErrorIs(t, err, ErrUnrecognizedEncoding)
+		var unknownId *UnknownIdError
+		require.True(t, errors.As(err, &unknownId))
+		assert.Equal(t, "unknown", unknownId.Id)
+		assert.True(t, encoder.UpgradeEncoding("{unknown}"+springBCrypt))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := encoder.Inspect("{scrypt}" + springBCrypt)
+		assert.ErrorIs(t, err, ErrUnrecognizedEncoding)
+	})
+}
+
+func TestPepperPasswordEncoder_Inspect(t *testing.T) {
+	encoder := NewPepperPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), "k2", map[string][]byte{"k1": []byte("1"), "k2": []byte("2")})
+
+	info, err := encoder.Inspect("{k1}" + springBCrypt)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", info.KeyId)
+	assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+	assert.Equal(t, true, info.UpgradeEncoding)
+
+	_, err = encoder.Inspect("{k3}" + springBCrypt)
+	assert.ErrorIs(t, err, ErrUnrecognizedEncoding)
+}
+
+func TestEncryptingPasswordEncoder_Inspect(t *testing.T) {
+	encoder := NewEncryptingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), "k1", map[string]cipher.AEAD{
+		"k1": mustAead(NewAesGcm(make([]byte, 32))),
+	})
+
+	encryptedPassword, err := encoder.Encode("password")
+	require.NoError(t, err)
+
+	info, err := encoder.Inspect(encryptedPassword)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", info.KeyId)
+	assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+	assert.Equal(t, bcrypt.MinCost, info.Cost)
+	assert.Equal(t, false, info.UpgradeEncoding)
+
+	_, err = encoder.Inspect("{k1}_")
+	assert.ErrorIs(t, err, ErrUnrecognizedEncoding)
+}
+
+func TestSm3Pbkdf2PasswordEncoder_Inspect(t *testing.T) {
+	encoder := NewSm3Pbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 2000, 32)
+
+	info, err := encoder.Inspect("$pbkdf2-sm3$i=1000$AAECAwQFBgcICQoLDA0ODw$MEwyS1xzWE068inyJLnAwqfoVBrZnpdtRhghJfOEPw0")
+	require.NoError(t, err)
+	assert.Equal(t, HashInfo{Algorithm: AlgorithmPbkdf2Sm3, Strength: StrengthStrong, Iterations: 1000, SaltLength: 16, KeyLength: 32, UpgradeEncoding: true}, info)
+
+	legacyEncodedPassword, err := NewSm3PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16)).Encode("password")
+	require.NoError(t, err)
+	info, err = encoder.Inspect(legacyEncodedPassword)
+	require.NoError(t, err)
+	assert.Equal(t, HashInfo{Algorithm: AlgorithmSm3, Strength: StrengthLegacy, SaltLength: 16, KeyLength: 32, UpgradeEncoding: true}, info)
+}
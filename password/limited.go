@@ -0,0 +1,222 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// MemoryEstimator is implemented by memory-hard PasswordEncoders such as SCryptPasswordEncoder.
+type MemoryEstimator interface {
+	// EstimateMemory returns the estimated peak memory in bytes to verify encodedPassword,
+	// or to encode a new password if encodedPassword is "". 0 if unknown.
+	EstimateMemory(encodedPassword string) int64
+}
+
+// EstimateMemory returns encoder.EstimateMemory(encodedPassword) if encoder is a MemoryEstimator, otherwise 0.
+func EstimateMemory(encoder PasswordEncoder, encodedPassword string) int64 {
+	if e, ok := encoder.(MemoryEstimator); ok {
+		return e.EstimateMemory(encodedPassword)
+	}
+	return 0
+}
+
+// LimiterStats is a snapshot of a LimitedPasswordEncoder.
+type LimiterStats struct {
+	InFlight    int64 // calls running in the delegate
+	Waiting     int64 // calls queued for the limiter
+	MemoryInUse int64 // estimated bytes held by calls in flight
+
+	Admitted  uint64        // calls admitted since creation
+	Abandoned uint64        // calls whose context was done while queued
+	TotalWait time.Duration // sum of queueing time of all calls
+	MaxWait   time.Duration
+}
+
+type LimitOption func(e *LimitedPasswordEncoder)
+
+// WithMemoryLimit additionally bounds the estimated memory of calls in flight,
+// each call weighs MemoryEstimator.EstimateMemory of the delegate, at most memoryLimit.
+func WithMemoryLimit(memoryLimit int64) LimitOption {
+	return func(e *LimitedPasswordEncoder) {
+		e.memoryLimit = memoryLimit
+		e.memory = semaphore.NewWeighted(memoryLimit)
+	}
+}
+
+// WithQueueTimeout bounds the time a call waits for the limiter, in addition to the context deadline.
+// Without it Encode and Matches wait indefinitely.
+func WithQueueTimeout(queueTimeout time.Duration) LimitOption {
+	return func(e *LimitedPasswordEncoder) {
+		e.queueTimeout = queueTimeout
+	}
+}
+
+// LimitedPasswordEncoder bounds the concurrent Encode and Matches calls of another PasswordEncoder.
+//
+// Calls beyond the limit queue in FIFO order until admitted or their context is done.
+// Matches returns false for calls abandoned in the queue, MatchesContext returns the context error.
+type LimitedPasswordEncoder struct {
+	delegate PasswordEncoder
+
+	calls        *semaphore.Weighted
+	memory       *semaphore.Weighted // nil without WithMemoryLimit
+	memoryLimit  int64
+	queueTimeout time.Duration
+
+	mu    sync.Mutex
+	stats LimiterStats
+}
+
+var _ ContextPasswordEncoder = (*LimitedPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*LimitedPasswordEncoder)(nil)
+
+// panics if maxConcurrent < 1
+func NewLimitedPasswordEncoder(delegate PasswordEncoder, maxConcurrent int64, opts ...LimitOption) *LimitedPasswordEncoder {
+	if maxConcurrent < 1 {
+		panic(fmt.Errorf("maxConcurrent %d must be positive", maxConcurrent))
+	}
+
+	e := &LimitedPasswordEncoder{
+		delegate: delegate,
+		calls:    semaphore.NewWeighted(maxConcurrent),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.memory != nil && e.memoryLimit < 1 {
+		panic(fmt.Errorf("memoryLimit %d must be positive", e.memoryLimit))
+	}
+	return e
+}
+
+func (e *LimitedPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *LimitedPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *LimitedPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *LimitedPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *LimitedPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *LimitedPasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *LimitedPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	release, err := e.acquire(ctx, EstimateMemory(e.delegate, ""))
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return encodeBytesContext(ctx, e.delegate, rawPassword)
+}
+
+func (e *LimitedPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	release, err := e.acquire(ctx, EstimateMemory(e.delegate, encodedPassword))
+	if err != nil {
+		return false, err
+	}
+	defer release()
+	return matchesBytesContext(ctx, e.delegate, rawPassword, encodedPassword)
+}
+
+// not limited
+func (e *LimitedPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	return e.delegate.UpgradeEncoding(encodedPassword)
+}
+
+// the Strength of the delegate
+func (e *LimitedPasswordEncoder) Strength() Strength {
+	return StrengthOf(e.delegate)
+}
+
+func (e *LimitedPasswordEncoder) EstimateMemory(encodedPassword string) int64 {
+	return EstimateMemory(e.delegate, encodedPassword)
+}
+
+func (e *LimitedPasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	return inspectWith(e.delegate, encodedPassword)
+}
+
+func (e *LimitedPasswordEncoder) Stats() LimiterStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+func (e *LimitedPasswordEncoder) acquire(ctx context.Context, memory int64) (release func(), err error) {
+	// semaphore.Weighted may admit a done context
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if e.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.queueTimeout)
+		defer cancel()
+	}
+
+	if e.memory == nil || memory < 0 {
+		memory = 0
+	}
+	if memory > e.memoryLimit {
+		memory = e.memoryLimit // would never be admitted otherwise
+	}
+
+	e.mu.Lock()
+	e.stats.Waiting++
+	e.mu.Unlock()
+
+	start := time.Now()
+	err = e.calls.Acquire(ctx, 1)
+	if err == nil && memory > 0 {
+		if err = e.memory.Acquire(ctx, memory); err != nil {
+			e.calls.Release(1)
+		}
+	}
+	wait := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats.Waiting--
+	e.stats.TotalWait += wait
+	if wait > e.stats.MaxWait {
+		e.stats.MaxWait = wait
+	}
+	if err != nil {
+		e.stats.Abandoned++
+		return nil, err
+	}
+	e.stats.Admitted++
+	e.stats.InFlight++
+	e.stats.MemoryInUse += memory
+
+	return func() {
+		if memory > 0 {
+			e.memory.Release(memory)
+		}
+		e.calls.Release(1)
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.stats.InFlight--
+		e.stats.MemoryInUse -= memory
+	}, nil
+}
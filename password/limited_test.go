@@ -0,0 +1,182 @@
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// blocks Encode and Matches until unblock is closed
+type blockingPasswordEncoder struct {
+	PasswordEncoder
+	entered chan struct{}
+	unblock chan struct{}
+	memory  int64
+}
+
+func newBlockingPasswordEncoder(memory int64) *blockingPasswordEncoder {
+	return &blockingPasswordEncoder{
+		PasswordEncoder: NopPasswordEncoder(),
+		entered:         make(chan struct{}, 16),
+		unblock:         make(chan struct{}),
+		memory:          memory,
+	}
+}
+
+func (e *blockingPasswordEncoder) Encode(rawPassword string) (string, error) {
+	e.entered <- struct{}{}
+	<-e.unblock
+	return e.PasswordEncoder.Encode(rawPassword)
+}
+
+func (e *blockingPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	e.entered <- struct{}{}
+	<-e.unblock
+	return e.PasswordEncoder.Matches(rawPassword, encodedPassword)
+}
+
+func (e *blockingPasswordEncoder) EstimateMemory(encodedPassword string) int64 {
+	return e.memory
+}
+
+func TestNewLimitedPasswordEncoder(t *testing.T) {
+	assert.Panics(t, func() {
+		NewLimitedPasswordEncoder(NopPasswordEncoder(), 0)
+	})
+	assert.Panics(t, func() {
+		NewLimitedPasswordEncoder(NopPasswordEncoder(), 1, WithMemoryLimit(0))
+	})
+}
+
+func TestLimitedPasswordEncoder(t *testing.T) {
+	delegate := newBlockingPasswordEncoder(0)
+	encoder := NewLimitedPasswordEncoder(delegate, 1)
+
+	done := make(chan string)
+	go func() {
+		encodedPassword, _ := encoder.Encode("password")
+		done <- encodedPassword
+	}()
+	<-delegate.entered
+
+	t.Run("queues until context done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := encoder.EncodeContext(ctx, "password")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		matched, err := encoder.MatchesContext(ctx, "password", "password")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, matched)
+
+		stats := encoder.Stats()
+		assert.Equal(t, int64(1), stats.InFlight)
+		assert.Equal(t, int64(0), stats.Waiting)
+		assert.Equal(t, uint64(1), stats.Admitted)
+		assert.Equal(t, uint64(2), stats.Abandoned)
+		assert.GreaterOrEqual(t, stats.MaxWait, 10*time.Millisecond)
+	})
+
+	t.Run("admits in turn", func(t *testing.T) {
+		matched := make(chan bool)
+		go func() {
+			matched <- encoder.Matches("password", "password")
+		}()
+		assert.Eventually(t, func() bool { return encoder.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+
+		close(delegate.unblock)
+		assert.Equal(t, "password", <-done)
+		assert.True(t, <-matched)
+
+		stats := encoder.Stats()
+		assert.Equal(t, int64(0), stats.InFlight)
+		assert.Equal(t, int64(0), stats.Waiting)
+		assert.Equal(t, uint64(2), stats.Admitted)
+	})
+}
+
+func TestLimitedPasswordEncoder_WithMemoryLimit(t *testing.T) {
+	delegate := newBlockingPasswordEncoder(60)
+	encoder := NewLimitedPasswordEncoder(delegate, 10, WithMemoryLimit(100), WithQueueTimeout(10*time.Millisecond))
+
+	done := make(chan bool)
+	go func() {
+		done <- encoder.Matches("password", "password")
+	}()
+	<-delegate.entered
+	assert.Equal(t, int64(60), encoder.Stats().MemoryInUse)
+
+	// 60 + 60 > 100
+	assert.False(t, encoder.Matches("password", "password"))
+	assert.Equal(t, uint64(1), encoder.Stats().Abandoned)
+
+	close(delegate.unblock)
+	assert.True(t, <-done)
+	assert.Equal(t, int64(0), encoder.Stats().MemoryInUse)
+
+	t.Run("estimate above limit", func(t *testing.T) {
+		encoder := NewLimitedPasswordEncoder(newBlockingPasswordEncoder(1000), 1, WithMemoryLimit(100))
+		close(encoder.delegate.(*blockingPasswordEncoder).unblock)
+		assert.True(t, encoder.Matches("password", "password"))
+	})
+}
+
+func TestLimitedPasswordEncoder_cancelled(t *testing.T) {
+	encoder := NewLimitedPasswordEncoder(NopPasswordEncoder(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := encoder.EncodeContext(ctx, "password")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, uint64(0), encoder.Stats().Admitted)
+}
+
+func TestSCryptPasswordEncoder_EstimateMemory(t *testing.T) {
+	encoder := NewSCryptPasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 16384, 8, 1, 32)
+	assert.Equal(t, int64(128*8*(16384+1+2)), encoder.EstimateMemory(""))
+	assert.Equal(t, int64(128*8*(65536+1+2)), DefaultSCryptPasswordEncoder().EstimateMemory(""))
+
+	encodedPassword := "$e0801$AAECAwQFBgcICQoLDA0ODw==$6iMJXpgeItuXSS3ial5ceU6o+LQA0aKIA8ORmTlhNMU="
+	assert.Equal(t, int64(128*8*(16384+1+2)), DefaultSCryptPasswordEncoder().EstimateMemory(encodedPassword))
+	assert.Equal(t, int64(0), encoder.EstimateMemory("$$"))
+}
+
+func TestDelegatingPasswordEncoder_WithLimit(t *testing.T) {
+	blocking := newBlockingPasswordEncoder(0)
+	encoder := NewDelegatingPasswordEncoder("blocking", map[string]PasswordEncoder{
+		"blocking": blocking,
+		"noop":     NopPasswordEncoder(),
+		"scrypt":   NewSCryptPasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 16384, 8, 1, 32),
+	}, WithLimit(1))
+
+	done := make(chan bool)
+	go func() {
+		done <- encoder.Matches("password", "{blocking}password")
+	}()
+	<-blocking.entered
+
+	// not queued behind "blocking"
+	assert.True(t, encoder.Matches("password", "{noop}password"))
+
+	stats := encoder.LimiterStats()
+	require.Len(t, stats, 3)
+	assert.Equal(t, int64(1), stats["blocking"].InFlight)
+	assert.Equal(t, uint64(1), stats["noop"].Admitted)
+	assert.Equal(t, uint64(0), stats["scrypt"].Admitted)
+
+	close(blocking.unblock)
+	assert.True(t, <-done)
+
+	assert.Equal(t, int64(128*8*(16384+1+2)), encoder.EstimateMemory("{scrypt}$e0801$AAECAwQFBgcICQoLDA0ODw==$6iMJXpgeItuXSS3ial5ceU6o+LQA0aKIA8ORmTlhNMU="))
+	assert.Equal(t, int64(0), encoder.EstimateMemory(""))
+	assert.Equal(t, int64(0), encoder.EstimateMemory("{unknown}"))
+}
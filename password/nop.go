@@ -1,5 +1,7 @@
 package password
 
+import "context"
+
 // Deprecated. For testing purposes only
 func NopPasswordEncoder() PasswordEncoder {
 	return nopPasswordEncoder{}
@@ -7,14 +9,58 @@ func NopPasswordEncoder() PasswordEncoder {
 
 type nopPasswordEncoder struct{}
 
+var _ ContextPasswordEncoder = nopPasswordEncoder{}
+var _ BytesPasswordEncoder = nopPasswordEncoder{}
+
 func (e nopPasswordEncoder) Encode(rawPassword string) (string, error) {
-	return rawPassword, nil
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e nopPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e nopPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
 }
 
 func (e nopPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
-	return rawPassword == encodedPassword
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e nopPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e nopPasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e nopPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return string(rawPassword), nil
+}
+
+func (e nopPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return string(rawPassword) == encodedPassword, nil
 }
 
+// always true, plaintext passwords should be upgraded
 func (e nopPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
-	return false
+	return true
+}
+
+func (e nopPasswordEncoder) Strength() Strength {
+	return StrengthInsecure
+}
+
+func (e nopPasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	return HashInfo{Algorithm: AlgorithmNoop, Strength: e.Strength(), UpgradeEncoding: true}, nil
 }
@@ -59,9 +59,9 @@ func TestNopPasswordEncoder_Matches(t *testing.T) {
 }
 
 func TestNopPasswordEncoder_UpgradeEncoding(t *testing.T) {
-	t.Run("always false", func(t *testing.T) {
+	t.Run("always true", func(t *testing.T) {
 		encoder := NopPasswordEncoder()
 
-		assert.Equal(t, false, encoder.UpgradeEncoding("password"))
+		assert.Equal(t, true, encoder.UpgradeEncoding("password"))
 	})
 }
@@ -0,0 +1,225 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/secure/precis"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrRejectedPassword is returned by NormalizingPasswordEncoder.Encode for raw passwords
+// its Preparation rejects, e.g. empty or with control characters.
+var ErrRejectedPassword = errors.New("password: raw password rejected")
+
+// Preparation normalizes a raw password before encoding, or rejects it with an error.
+// Id is recorded in the encoded password, e.g. "{opaque}$2a$10$...".
+//
+// Prepare must not modify rawPassword. NormalizingPasswordEncoder zeroes the result after use,
+// unless it is rawPassword itself.
+type Preparation struct {
+	Id      string
+	Prepare func(rawPassword []byte) ([]byte, error)
+}
+
+// OpaqueString is the PRECIS OpaqueString profile of RFC 8265 (the successor of SASLprep):
+// non-ASCII spaces are mapped to ASCII space, the result is NFC normalized,
+// and empty passwords, invalid UTF-8 and control characters are rejected.
+var OpaqueString = Preparation{
+	Id: "opaque",
+	Prepare: func(rawPassword []byte) ([]byte, error) {
+		if !utf8.Valid(rawPassword) {
+			return nil, errors.New("invalid UTF-8") // precis replaces it with U+FFFD
+		}
+		return precis.OpaqueString.Bytes(rawPassword)
+	},
+}
+
+// NFKC normalizes compatibility characters as well, e.g. full-width digits and ligatures,
+// and rejects empty passwords, invalid UTF-8 and control characters.
+var NFKC = Preparation{
+	Id: "nfkc",
+	Prepare: func(rawPassword []byte) ([]byte, error) {
+		if len(rawPassword) == 0 {
+			return nil, errors.New("empty password")
+		}
+		if !utf8.Valid(rawPassword) {
+			return nil, errors.New("invalid UTF-8")
+		}
+		for _, r := range string(rawPassword) {
+			if unicode.IsControl(r) {
+				return nil, fmt.Errorf("control character %U", r)
+			}
+		}
+		return norm.NFKC.Append(nil, rawPassword...), nil // Bytes returns rawPassword if it is normalized
+	},
+}
+
+// preparations verify hashes of the built-in Preparations after a configuration change
+var preparations = map[string]Preparation{
+	OpaqueString.Id: OpaqueString,
+	NFKC.Id:         NFKC,
+}
+
+// NormalizingPasswordEncoder prepares raw passwords with a Preparation before delegating to another PasswordEncoder,
+// so that the same password matches whatever Unicode normalization the client used, e.g. NFD on macOS.
+//
+// The Preparation id is recorded in the encoded password, e.g. "{opaque}$2a$10$...".
+// Encoded passwords without it, i.e. encoded by the delegate alone, keep verifying with the raw password verbatim
+// and UpgradeEncoding returns true for them, as for those of another built-in Preparation.
+type NormalizingPasswordEncoder struct {
+	idPrefix string
+	idSuffix string
+
+	delegate    PasswordEncoder
+	preparation Preparation
+}
+
+var _ ContextPasswordEncoder = (*NormalizingPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*NormalizingPasswordEncoder)(nil)
+
+// panics if preparation has no Id or Prepare
+func NewNormalizingPasswordEncoder(delegate PasswordEncoder, preparation Preparation) *NormalizingPasswordEncoder {
+	if preparation.Id == "" || preparation.Prepare == nil {
+		panic(fmt.Errorf("preparation %q must have an Id and Prepare", preparation.Id))
+	}
+	return &NormalizingPasswordEncoder{
+		idPrefix:    DefaultIdPrefix,
+		idSuffix:    DefaultIdSuffix,
+		delegate:    delegate,
+		preparation: preparation,
+	}
+}
+
+func (e *NormalizingPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *NormalizingPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *NormalizingPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *NormalizingPasswordEncoder) Matches(rawPassword string, prefixEncodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, prefixEncodedPassword)
+	return matched
+}
+
+func (e *NormalizingPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, prefixEncodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, prefixEncodedPassword)
+}
+
+func (e *NormalizingPasswordEncoder) MatchesBytes(rawPassword []byte, prefixEncodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, prefixEncodedPassword)
+	return matched
+}
+
+// Prepare returns rawPassword as it is encoded, or an error wrapping ErrRejectedPassword
+func (e *NormalizingPasswordEncoder) Prepare(rawPassword string) (string, error) {
+	prepared, err := prepare(e.preparation, []byte(rawPassword))
+	if err != nil {
+		return "", err
+	}
+	return string(prepared), nil
+}
+
+func (e *NormalizingPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	prepared, err := prepare(e.preparation, rawPassword)
+	if err != nil {
+		return "", err
+	}
+	defer zero(prepared)
+
+	encodedPassword, err := encodeBytesContext(ctx, e.delegate, prepared)
+	if err != nil {
+		return "", err
+	}
+	return e.idPrefix + e.preparation.Id + e.idSuffix + encodedPassword, nil
+}
+
+func (e *NormalizingPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, prefixEncodedPassword string) (bool, error) {
+	preparation, encodedPassword, ok := e.cut(prefixEncodedPassword)
+	if !ok {
+		// not normalized
+		return matchesBytesContext(ctx, e.delegate, rawPassword, prefixEncodedPassword)
+	}
+
+	prepared, err := prepare(preparation, rawPassword)
+	if err != nil {
+		return false, nil // cannot have been encoded
+	}
+	defer zero(prepared)
+	return matchesBytesContext(ctx, e.delegate, prepared, encodedPassword)
+}
+
+// true if prefixEncodedPassword is not normalized by the configured Preparation, or the delegate says so
+func (e *NormalizingPasswordEncoder) UpgradeEncoding(prefixEncodedPassword string) bool {
+	preparation, encodedPassword, ok := e.cut(prefixEncodedPassword)
+	if !ok || preparation.Id != e.preparation.Id {
+		return true
+	}
+	return e.delegate.UpgradeEncoding(encodedPassword)
+}
+
+// the Strength of the delegate
+func (e *NormalizingPasswordEncoder) Strength() Strength {
+	return StrengthOf(e.delegate)
+}
+
+func (e *NormalizingPasswordEncoder) EstimateMemory(prefixEncodedPassword string) int64 {
+	if _, encodedPassword, ok := e.cut(prefixEncodedPassword); ok {
+		return EstimateMemory(e.delegate, encodedPassword)
+	}
+	return EstimateMemory(e.delegate, prefixEncodedPassword)
+}
+
+func (e *NormalizingPasswordEncoder) Inspect(prefixEncodedPassword string) (HashInfo, error) {
+	preparation, encodedPassword, ok := e.cut(prefixEncodedPassword)
+	if !ok {
+		encodedPassword = prefixEncodedPassword
+	}
+
+	info, err := inspectWith(e.delegate, encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Preparation = preparation.Id
+	info.UpgradeEncoding = e.UpgradeEncoding(prefixEncodedPassword)
+	return info, nil
+}
+
+// cut returns the Preparation recorded in prefixEncodedPassword, false if none
+func (e *NormalizingPasswordEncoder) cut(prefixEncodedPassword string) (Preparation, string, bool) {
+	if !strings.HasPrefix(prefixEncodedPassword, e.idPrefix) {
+		return Preparation{}, "", false
+	}
+	id := extractId(prefixEncodedPassword, e.idPrefix, e.idSuffix)
+
+	preparation, ok := preparations[id]
+	if id == e.preparation.Id {
+		preparation, ok = e.preparation, true
+	}
+	if !ok {
+		return Preparation{}, "", false
+	}
+	return preparation, extractEncodedPassword(prefixEncodedPassword, e.idSuffix), true
+}
+
+func prepare(preparation Preparation, rawPassword []byte) ([]byte, error) {
+	prepared, err := preparation.Prepare(rawPassword)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRejectedPassword, err)
+	}
+	if len(prepared) > 0 && len(rawPassword) > 0 && &prepared[0] == &rawPassword[0] {
+		// owned by the caller, not to be zeroed
+		prepared = append([]byte(nil), prepared...)
+	}
+	return prepared, nil
+}
@@ -0,0 +1,129 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	nfc = "café"  // "café" as typed on Windows
+	nfd = "café" // "café" as typed on macOS
+)
+
+func TestNewNormalizingPasswordEncoder(t *testing.T) {
+	t.Run("panics without id", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), Preparation{Prepare: NFKC.Prepare})
+		})
+	})
+}
+
+func TestNormalizingPasswordEncoder_Matches(t *testing.T) {
+	for _, preparation := range []Preparation{OpaqueString, NFKC} {
+		t.Run(preparation.Id, func(t *testing.T) {
+			encoder := NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), preparation)
+
+			encodedPassword, err := encoder.Encode(nfd)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encodedPassword, "{"+preparation.Id+"}$2a$04$"))
+			assert.True(t, encoder.Matches(nfc, encodedPassword))
+			assert.True(t, encoder.Matches(nfd, encodedPassword))
+			assert.False(t, encoder.Matches("cafe", encodedPassword))
+			assert.False(t, encoder.Matches("", encodedPassword))
+			assert.False(t, encoder.UpgradeEncoding(encodedPassword))
+		})
+	}
+
+	t.Run("NFKC compatibility characters", func(t *testing.T) {
+		encoder := NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), NFKC)
+		encodedPassword, err := encoder.Encode("ｐａｓｓ７") // full-width "pass7"
+		require.NoError(t, err)
+		assert.True(t, encoder.Matches("pass7", encodedPassword))
+	})
+
+	t.Run("Prepare returning rawPassword", func(t *testing.T) {
+		identity := Preparation{Id: "identity", Prepare: func(rawPassword []byte) ([]byte, error) { return rawPassword, nil }}
+		encoder := NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), identity)
+		rawPassword := []byte("password")
+		encodedPassword, err := encoder.EncodeBytes(rawPassword)
+		require.NoError(t, err)
+		assert.True(t, encoder.MatchesBytes(rawPassword, encodedPassword))
+		assert.Equal(t, []byte("password"), rawPassword, "not zeroed")
+	})
+
+	t.Run("OpaqueString spaces", func(t *testing.T) {
+		encoder := NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), OpaqueString)
+		encodedPassword, err := encoder.Encode("correct horse") // no-break space
+		require.NoError(t, err)
+		assert.True(t, encoder.Matches("correct horse", encodedPassword))
+	})
+}
+
+func TestNormalizingPasswordEncoder_Encode(t *testing.T) {
+	for _, preparation := range []Preparation{OpaqueString, NFKC} {
+		encoder := NewNormalizingPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), preparation)
+		for _, rawPassword := range []string{"", "pass\x00word", "pass\nword", "pass\x7fword", "\xff"} {
+			_, err := encoder.Encode(rawPassword)
+			assert.ErrorIs(t, err, ErrRejectedPassword, "%s %q", preparation.Id, rawPassword)
+		}
+	}
+
+	prepared, err := NewNormalizingPasswordEncoder(NopPasswordEncoder(), NFKC).Prepare(nfd)
+	require.NoError(t, err)
+	assert.Equal(t, nfc, prepared)
+}
+
+func TestNormalizingPasswordEncoder_UpgradeEncoding(t *testing.T) {
+	delegate := NewBCryptPasswordEncoder(bcrypt.MinCost)
+	encoder := NewNormalizingPasswordEncoder(delegate, OpaqueString)
+
+	t.Run("legacy verbatim", func(t *testing.T) {
+		legacy, err := delegate.Encode(nfd)
+		require.NoError(t, err)
+
+		assert.True(t, encoder.Matches(nfd, legacy))
+		assert.False(t, encoder.Matches(nfc, legacy))
+		assert.True(t, encoder.UpgradeEncoding(legacy))
+
+		// the empty password of a legacy hash is not rejected
+		legacy, err = delegate.Encode("")
+		require.NoError(t, err)
+		assert.True(t, encoder.Matches("", legacy))
+	})
+
+	t.Run("other preparation", func(t *testing.T) {
+		encodedPassword, err := NewNormalizingPasswordEncoder(delegate, NFKC).Encode(nfd)
+		require.NoError(t, err)
+
+		assert.True(t, encoder.Matches(nfc, encodedPassword))
+		assert.True(t, encoder.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("delegating", func(t *testing.T) {
+		delegating := NewDelegatingPasswordEncoder("bcrypt", map[string]PasswordEncoder{
+			"bcrypt": encoder,
+		})
+		encodedPassword, err := delegating.Encode(nfd)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encodedPassword, "{bcrypt}{opaque}$2a$"))
+		assert.True(t, delegating.Matches(nfc, encodedPassword))
+		assert.False(t, delegating.UpgradeEncoding(encodedPassword))
+		assert.True(t, delegating.UpgradeEncoding("{bcrypt}$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG"))
+
+		info, err := delegating.Inspect(encodedPassword)
+		require.NoError(t, err)
+		assert.Equal(t, "opaque", info.Preparation)
+		assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+		assert.False(t, info.UpgradeEncoding)
+
+		info, err = Inspect(encodedPassword)
+		require.NoError(t, err)
+		assert.Equal(t, "bcrypt", info.Id)
+		assert.Equal(t, "opaque", info.Preparation)
+		assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+	})
+}
@@ -0,0 +1,166 @@
+package password
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/emmansun/gmsm/sm3"
+)
+
+// legacyDigester is a salted single-pass digest, encoded as hex(salt + digest(salt + rawPassword))
+type legacyDigester interface {
+	PasswordEncoder
+	bytesContextEncoder
+	digest(rawPassword []byte, salt []byte) []byte // salt + digest
+	digestSize() int
+}
+
+func (e *Sha256PasswordEncoder) digestSize() int { return sha256.Size }
+
+func (e *Sm3PasswordEncoder) digestSize() int { return sm3.Size }
+
+// onionSeparator separates the hex salt of the legacy digest from the outer encoded password
+const onionSeparator = ":"
+
+// OnionPasswordEncoder wraps the digest of a legacy Sha256PasswordEncoder or Sm3PasswordEncoder
+// in a strong PasswordEncoder, so that legacy hashes can be migrated offline without the raw passwords.
+//
+// The encoded password is "<hex salt>:<outer encoded password>", where the outer encoder encodes
+// the hex digest of the legacy encoder, e.g. bcrypt(hex(sha256(salt + rawPassword))).
+// Register it with its own id, e.g. "sha256-bcrypt"; UpgradeEncoding always returns true
+// so that onion hashes are re-encoded with the outer encoder alone on the next login.
+type OnionPasswordEncoder struct {
+	inner legacyDigester
+	outer PasswordEncoder
+}
+
+var _ ContextPasswordEncoder = (*OnionPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*OnionPasswordEncoder)(nil)
+
+// panics if inner is not a *Sha256PasswordEncoder or *Sm3PasswordEncoder
+func NewOnionPasswordEncoder(inner PasswordEncoder, outer PasswordEncoder) *OnionPasswordEncoder {
+	digester, ok := inner.(legacyDigester)
+	if !ok {
+		panic(fmt.Errorf("inner %T is not a *Sha256PasswordEncoder or *Sm3PasswordEncoder", inner))
+	}
+	return &OnionPasswordEncoder{inner: digester, outer: outer}
+}
+
+func (e *OnionPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *OnionPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *OnionPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *OnionPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *OnionPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *OnionPasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *OnionPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	legacyEncodedPassword, err := e.inner.encodeBytesContext(ctx, rawPassword)
+	if err != nil {
+		return "", err
+	}
+	return e.WrapContext(ctx, legacyEncodedPassword)
+}
+
+func (e *OnionPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	salt, outerEncodedPassword, ok := decodeOnion(encodedPassword)
+	if !ok {
+		return false, nil
+	}
+	saltDigest := e.inner.digest(rawPassword, salt)
+	defer zero(saltDigest)
+	hexDigest := hexBytes(saltDigest[len(salt):])
+	defer zero(hexDigest)
+	return matchesBytesContext(ctx, e.outer, hexDigest, outerEncodedPassword)
+}
+
+// Wrap converts a password encoded by the inner encoder into an onion encoded password.
+// Returns ErrUnrecognizedEncoding if legacyEncodedPassword is not hex(salt + digest).
+func (e *OnionPasswordEncoder) Wrap(legacyEncodedPassword string) (string, error) {
+	return e.WrapContext(context.Background(), legacyEncodedPassword)
+}
+
+func (e *OnionPasswordEncoder) WrapContext(ctx context.Context, legacyEncodedPassword string) (string, error) {
+	digested, err := hex.DecodeString(legacyEncodedPassword)
+	if err != nil || len(digested) < e.inner.digestSize() {
+		return "", ErrUnrecognizedEncoding
+	}
+	salt, digest := digested[:len(digested)-e.inner.digestSize()], digested[len(digested)-e.inner.digestSize():]
+	hexDigest := hexBytes(digest)
+	defer zero(hexDigest)
+
+	encodedPassword, err := encodeBytesContext(ctx, e.outer, hexDigest)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt) + onionSeparator + encodedPassword, nil
+}
+
+// always true, the legacy digest is only as strong as the outer encoder without it
+func (e *OnionPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	return true
+}
+
+// the Strength of the outer encoder
+func (e *OnionPasswordEncoder) Strength() Strength {
+	return StrengthOf(e.outer)
+}
+
+func (e *OnionPasswordEncoder) EstimateMemory(encodedPassword string) int64 {
+	if encodedPassword == "" {
+		return EstimateMemory(e.outer, "")
+	}
+	_, outerEncodedPassword, ok := decodeOnion(encodedPassword)
+	if !ok {
+		return 0
+	}
+	return EstimateMemory(e.outer, outerEncodedPassword)
+}
+
+func (e *OnionPasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	_, outerEncodedPassword, ok := decodeOnion(encodedPassword)
+	if !ok {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+	info, err := inspectWith(e.outer, outerEncodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Onion = true
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+// decode "<hex salt>:<outer encoded password>"
+func decodeOnion(encodedPassword string) (salt []byte, outerEncodedPassword string, ok bool) {
+	i := strings.Index(encodedPassword, onionSeparator)
+	if i < 0 {
+		return nil, "", false
+	}
+	salt, err := hex.DecodeString(encodedPassword[:i])
+	if err != nil {
+		return nil, "", false
+	}
+	return salt, encodedPassword[i+len(onionSeparator):], true
+}
@@ -0,0 +1,104 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+func TestNewOnionPasswordEncoder(t *testing.T) {
+	t.Run("panics inner not legacy", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewOnionPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), NewBCryptPasswordEncoder(bcrypt.MinCost))
+		})
+	})
+}
+
+func TestOnionPasswordEncoder_Wrap(t *testing.T) {
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(8)
+	for name, inner := range map[string]PasswordEncoder{
+		"sha256": NewSha256PasswordEncoder(saltGen),
+		"sm3":    NewSm3PasswordEncoder(saltGen),
+	} {
+		t.Run(name, func(t *testing.T) {
+			encoder := NewOnionPasswordEncoder(inner, NewBCryptPasswordEncoder(bcrypt.MinCost))
+
+			legacyEncodedPassword, err := inner.Encode("password")
+			require.NoError(t, err)
+
+			encodedPassword, err := encoder.Wrap(legacyEncodedPassword)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encodedPassword, legacyEncodedPassword[:16]+":$2a$04$"))
+			assert.True(t, encoder.Matches("password", encodedPassword))
+			assert.False(t, encoder.Matches("password1", encodedPassword))
+
+			// the salt is not secret, but part of the digest
+			assert.False(t, encoder.Matches("password", "0000000000000000"+encodedPassword[16:]))
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		encoder := NewOnionPasswordEncoder(NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(8)), NewBCryptPasswordEncoder(bcrypt.MinCost))
+		for _, legacyEncodedPassword := range []string{"", "zz", "0001", "$2a$04$abc"} {
+			_, err := encoder.Wrap(legacyEncodedPassword)
+			assert.ErrorIs(t, err, ErrUnrecognizedEncoding, legacyEncodedPassword)
+		}
+	})
+}
+
+func TestOnionPasswordEncoder_Matches(t *testing.T) {
+	encoder := NewOnionPasswordEncoder(NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(8)), NewBCryptPasswordEncoder(bcrypt.MinCost))
+
+	encodedPassword, err := encoder.Encode("password")
+	require.NoError(t, err)
+	assert.True(t, encoder.Matches("password", encodedPassword))
+	assert.False(t, encoder.Matches("", encodedPassword))
+
+	assert.False(t, encoder.Matches("password", ""))
+	assert.False(t, encoder.Matches("password", "$2a$04$abc"))
+	assert.False(t, encoder.Matches("password", "zz:"+encodedPassword[17:]))
+}
+
+func TestOnionPasswordEncoder_UpgradeEncoding(t *testing.T) {
+	encoder := NewOnionPasswordEncoder(NewSm3PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(8)), NewBCryptPasswordEncoder(bcrypt.MinCost))
+	encodedPassword, err := encoder.Encode("password")
+	require.NoError(t, err)
+	assert.True(t, encoder.UpgradeEncoding(encodedPassword))
+	assert.Equal(t, StrengthStrong, encoder.Strength())
+
+	t.Run("delegating", func(t *testing.T) {
+		delegating := NewDelegatingPasswordEncoder("bcrypt", map[string]PasswordEncoder{
+			"bcrypt":     NewBCryptPasswordEncoder(bcrypt.MinCost),
+			"sm3-bcrypt": encoder,
+		})
+		assert.True(t, delegating.Matches("password", "{sm3-bcrypt}"+encodedPassword))
+		assert.True(t, delegating.UpgradeEncoding("{sm3-bcrypt}"+encodedPassword))
+	})
+}
+
+func TestOnionPasswordEncoder_Inspect(t *testing.T) {
+	encoder := NewOnionPasswordEncoder(NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(8)), NewBCryptPasswordEncoder(bcrypt.MinCost))
+	encodedPassword, err := encoder.Encode("password")
+	require.NoError(t, err)
+
+	info, err := encoder.Inspect(encodedPassword)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+	assert.Equal(t, bcrypt.MinCost, info.Cost)
+	assert.True(t, info.Onion)
+	assert.True(t, info.UpgradeEncoding)
+
+	info, err = Inspect("{sha256-bcrypt}" + encodedPassword)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmBCrypt, info.Algorithm)
+	assert.True(t, info.Onion)
+	assert.True(t, info.UpgradeEncoding)
+
+	_, err = encoder.Inspect("$2a$04$abc")
+	assert.ErrorIs(t, err, ErrUnrecognizedEncoding)
+}
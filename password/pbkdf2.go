@@ -2,12 +2,11 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"hash"
 
-	"golang.org/x/crypto/pbkdf2"
-
 	"github.com/xuyang2/password-encoder/keygen"
 )
 
@@ -18,53 +17,115 @@ type Pbkdf2PasswordEncoder struct {
 	h       func() hash.Hash
 }
 
-var _ PasswordEncoder = (*Pbkdf2PasswordEncoder)(nil)
+var _ ContextPasswordEncoder = (*Pbkdf2PasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*Pbkdf2PasswordEncoder)(nil)
 
 // Pbkdf2PasswordEncoder.defaultsForSpringSecurity_v5_8()
 func DefaultPbkdf2PasswordEncoder() *Pbkdf2PasswordEncoder {
 	saltLen := 16
 	saltGen := keygen.NewSecureRandomBytesKeyGenerator(saltLen)
+	return NewPbkdf2PasswordEncoder(saltGen, 310000, sha256.Size, sha256.New) // 32 bytes for SHA-256
+}
+
+// panics if saltGen generates keys shorter than MinSaltLength
+func NewPbkdf2PasswordEncoder(saltGen keygen.BytesKeyGenerator, iter int, keyLen int, h func() hash.Hash) *Pbkdf2PasswordEncoder {
+	mustValidSaltGen(saltGen)
 	return &Pbkdf2PasswordEncoder{
 		saltGen: saltGen,
-		iter:    310000,
-		keyLen:  sha256.Size, // 32 bytes for SHA-256
-		h:       sha256.New,
+		iter:    iter,
+		keyLen:  keyLen,
+		h:       h,
 	}
 }
 
 func (e *Pbkdf2PasswordEncoder) Encode(rawPassword string) (string, error) {
-	salt, err := e.saltGen.GenerateKey()
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *Pbkdf2PasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *Pbkdf2PasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *Pbkdf2PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Pbkdf2PasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *Pbkdf2PasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Pbkdf2PasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	salt, err := generateSalt(e.saltGen)
+	if err != nil {
+		return "", err
+	}
+	saltKey, err := e.encode(ctx, rawPassword, salt)
 	if err != nil {
 		return "", err
 	}
-	saltKey := e.encode(rawPassword, salt)
 	return hex.EncodeToString(saltKey), nil
 }
 
-func (e *Pbkdf2PasswordEncoder) encode(rawPassword string, salt []byte) []byte {
-	key := pbkdf2.Key([]byte(rawPassword), salt, e.iter, e.keyLen, e.h)
-	saltKey := bytes.NewBuffer(make([]byte, 0, len(salt)+len(key)))
-	saltKey.Write(salt)
-	saltKey.Write(key)
-	return saltKey.Bytes()
-}
-
-func (e *Pbkdf2PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+func (e *Pbkdf2PasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
 	saltKey, err := hex.DecodeString(encodedPassword)
 	if err != nil {
-		return false
+		return false, nil
 	}
 
 	// extract salt
 	if len(saltKey) < e.keyLen {
-		return false
+		return false, nil
 	}
 	salt := make([]byte, len(saltKey)-e.keyLen)
 	copy(salt, saltKey[0:len(saltKey)-e.keyLen])
 
-	return bytes.Equal(saltKey, e.encode(rawPassword, salt))
+	generated, err := e.encode(ctx, rawPassword, salt)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(saltKey, generated), nil
+}
+
+func (e *Pbkdf2PasswordEncoder) encode(ctx context.Context, rawPassword []byte, salt []byte) ([]byte, error) {
+	key, err := pbkdf2Key(ctx, rawPassword, salt, e.iter, e.keyLen, e.h)
+	if err != nil {
+		return nil, err
+	}
+	saltKey := bytes.NewBuffer(make([]byte, 0, len(salt)+len(key)))
+	saltKey.Write(salt)
+	saltKey.Write(key)
+	return saltKey.Bytes(), nil
 }
 
 func (e *Pbkdf2PasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
 	return false
 }
+
+func (e *Pbkdf2PasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	info, err := inspectHex(encodedPassword, e.keyLen)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Algorithm = AlgorithmPbkdf2
+	info.Iterations = e.iter // not recorded in the encoded password
+	info.Strength = e.Strength()
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+func (e *Pbkdf2PasswordEncoder) Strength() Strength {
+	return StrengthStrong
+}
@@ -1,15 +1,29 @@
 package password
 
 import (
+	"crypto/sha256"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/xuyang2/password-encoder/keygen"
 	"github.com/xuyang2/password-encoder/keygen/keygentest"
 )
 
+func TestNewPbkdf2PasswordEncoder(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		assert.NotNil(t, NewPbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength), 310000, sha256.Size, sha256.New))
+	})
+
+	t.Run("panics salt too short", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewPbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength-1), 310000, sha256.Size, sha256.New)
+		})
+	})
+}
+
 func TestPbkdf2PasswordEncoder_Matches(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		encoder := DefaultPbkdf2PasswordEncoder()
@@ -47,6 +61,21 @@ func TestPbkdf2PasswordEncoder_Encode(t *testing.T) {
 		_, err := encoder.Encode("?")
 		assert.Error(t, err)
 	})
+
+	t.Run("golden", func(t *testing.T) {
+		encoder := DefaultPbkdf2PasswordEncoder()
+		encoder.saltGen = keygentest.FixedBytesKeyGenerator([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15})
+		encodedPassword, err := encoder.Encode("password")
+		assert.NoError(t, err)
+		assert.Equal(t, "000102030405060708090a0b0c0d0e0fe0f65a4bf6716253d2d10a7a4b18f35cd4baf31ff031a187cd0091674905482d", encodedPassword)
+	})
+
+	t.Run("short salt", func(t *testing.T) {
+		encoder := DefaultPbkdf2PasswordEncoder()
+		encoder.saltGen = keygentest.ShortBytesKeyGenerator(keygen.NewSecureRandomBytesKeyGenerator(16), 0)
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
 }
 
 func TestPbkdf2PasswordEncoder_UpgradeEncoding(t *testing.T) {
@@ -0,0 +1,139 @@
+package password
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+)
+
+// PepperPasswordEncoder HMACs the raw password with a server-side secret (pepper)
+// before delegating to another PasswordEncoder.
+//
+// The pepper key id is recorded in the encoded password, e.g. "{k1}$2a$10$...",
+// so that peppers can be rotated: hashes using a retired key keep verifying
+// and UpgradeEncoding returns true for them.
+type PepperPasswordEncoder struct {
+	idPrefix    string
+	idSuffix    string
+	idForEncode string
+
+	delegate   PasswordEncoder
+	idToPepper map[string][]byte
+	h          func() hash.Hash
+}
+
+var _ ContextPasswordEncoder = (*PepperPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*PepperPasswordEncoder)(nil)
+
+func NewPepperPasswordEncoder(delegate PasswordEncoder, idForEncode string, idToPepper map[string][]byte) *PepperPasswordEncoder {
+	if _, ok := idToPepper[idForEncode]; !ok {
+		panic(fmt.Errorf("idForEncode %q is not found in idToPepper", idForEncode))
+	}
+
+	return &PepperPasswordEncoder{
+		idPrefix:    DefaultIdPrefix,
+		idSuffix:    DefaultIdSuffix,
+		idForEncode: idForEncode,
+
+		delegate:   delegate,
+		idToPepper: idToPepper,
+		h:          sha256.New,
+	}
+}
+
+func (e *PepperPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *PepperPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *PepperPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *PepperPasswordEncoder) Matches(rawPassword string, prefixEncodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, prefixEncodedPassword)
+	return matched
+}
+
+func (e *PepperPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, prefixEncodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, prefixEncodedPassword)
+}
+
+func (e *PepperPasswordEncoder) MatchesBytes(rawPassword []byte, prefixEncodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, prefixEncodedPassword)
+	return matched
+}
+
+func (e *PepperPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	peppered := e.pepper(e.idToPepper[e.idForEncode], rawPassword)
+	defer zero(peppered)
+	encodedPassword, err := encodeBytesContext(ctx, e.delegate, peppered)
+	if err != nil {
+		return "", err
+	}
+	return e.idPrefix + e.idForEncode + e.idSuffix + encodedPassword, nil
+}
+
+func (e *PepperPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, prefixEncodedPassword string) (bool, error) {
+	id := extractId(prefixEncodedPassword, e.idPrefix, e.idSuffix)
+	key, ok := e.idToPepper[id]
+	if !ok {
+		return false, nil
+	}
+	encodedPassword := extractEncodedPassword(prefixEncodedPassword, e.idSuffix)
+	peppered := e.pepper(key, rawPassword)
+	defer zero(peppered)
+	return matchesBytesContext(ctx, e.delegate, peppered, encodedPassword)
+}
+
+func (e *PepperPasswordEncoder) UpgradeEncoding(prefixEncodedPassword string) bool {
+	id := extractId(prefixEncodedPassword, e.idPrefix, e.idSuffix)
+
+	if e.idForEncode != id {
+		return true
+	}
+
+	encodedPassword := extractEncodedPassword(prefixEncodedPassword, e.idSuffix)
+	return e.delegate.UpgradeEncoding(encodedPassword)
+}
+
+// the Strength of the delegate
+func (e *PepperPasswordEncoder) Strength() Strength {
+	return StrengthOf(e.delegate)
+}
+
+func (e *PepperPasswordEncoder) EstimateMemory(prefixEncodedPassword string) int64 {
+	if prefixEncodedPassword == "" {
+		return EstimateMemory(e.delegate, "")
+	}
+	return EstimateMemory(e.delegate, extractEncodedPassword(prefixEncodedPassword, e.idSuffix))
+}
+
+func (e *PepperPasswordEncoder) Inspect(prefixEncodedPassword string) (HashInfo, error) {
+	id := extractId(prefixEncodedPassword, e.idPrefix, e.idSuffix)
+	if _, ok := e.idToPepper[id]; !ok {
+		return HashInfo{}, ErrUnrecognizedEncoding
+	}
+
+	info, err := inspectWith(e.delegate, extractEncodedPassword(prefixEncodedPassword, e.idSuffix))
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.KeyId = id
+	info.UpgradeEncoding = e.UpgradeEncoding(prefixEncodedPassword)
+	return info, nil
+}
+
+// return hex(hmac(key, rawPassword)), 64 chars for SHA-256 which fits bcrypt's 72 bytes limit
+func (e *PepperPasswordEncoder) pepper(key []byte, rawPassword []byte) []byte {
+	mac := hmac.New(e.h, key)
+	mac.Write(rawPassword)
+	sum := mac.Sum(nil)
+	defer zero(sum)
+	return hexBytes(sum)
+}
@@ -0,0 +1,100 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewPepperPasswordEncoder(t *testing.T) {
+	t.Run("panics idForEncode not found", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewPepperPasswordEncoder(NewBCryptPasswordEncoder(bcrypt.MinCost), "k2", map[string][]byte{
+				"k1": []byte("secret1"),
+			})
+		})
+	})
+}
+
+func TestPepperPasswordEncoder_Matches(t *testing.T) {
+	delegate := NewBCryptPasswordEncoder(bcrypt.MinCost)
+	encoder := NewPepperPasswordEncoder(delegate, "k1", map[string][]byte{
+		"k1": []byte("secret1"),
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		rawPassword := "password"
+		encodedPassword, err := encoder.Encode(rawPassword)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encodedPassword, "{k1}$2a$"))
+		assert.True(t, encoder.Matches(rawPassword, encodedPassword))
+		assert.False(t, encoder.Matches(rawPassword+"a", encodedPassword))
+
+		assert.False(t, encoder.Matches(rawPassword, "{k0}"+extractEncodedPassword(encodedPassword, DefaultIdSuffix)))
+		assert.False(t, encoder.Matches(rawPassword, ""))
+	})
+
+	t.Run("database dump alone", func(t *testing.T) {
+		encodedPassword, err := encoder.Encode("password")
+		require.NoError(t, err)
+
+		assert.False(t, delegate.Matches("password", extractEncodedPassword(encodedPassword, DefaultIdSuffix)))
+	})
+
+	t.Run("wrong pepper", func(t *testing.T) {
+		encodedPassword, err := encoder.Encode("password")
+		require.NoError(t, err)
+
+		other := NewPepperPasswordEncoder(delegate, "k1", map[string][]byte{
+			"k1": []byte("secret2"),
+		})
+		assert.False(t, other.Matches("password", encodedPassword))
+	})
+}
+
+func TestPepperPasswordEncoder_Encode(t *testing.T) {
+	t.Run("err", func(t *testing.T) {
+		encoder := NewPepperPasswordEncoder(&errEncodePasswordEncoder{err: errors.New("WTF")}, "k1", map[string][]byte{
+			"k1": []byte("secret1"),
+		})
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
+}
+
+func TestPepperPasswordEncoder_UpgradeEncoding(t *testing.T) {
+	delegate := NewBCryptPasswordEncoder(bcrypt.MinCost)
+	old := NewPepperPasswordEncoder(delegate, "k1", map[string][]byte{
+		"k1": []byte("secret1"),
+	})
+	rotated := NewPepperPasswordEncoder(delegate, "k2", map[string][]byte{
+		"k1": []byte("secret1"),
+		"k2": []byte("secret2"),
+	})
+
+	encodedPassword, err := old.Encode("password")
+	require.NoError(t, err)
+
+	t.Run("current key", func(t *testing.T) {
+		assert.Equal(t, false, old.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("retired key", func(t *testing.T) {
+		assert.True(t, rotated.Matches("password", encodedPassword))
+		assert.Equal(t, true, rotated.UpgradeEncoding(encodedPassword))
+
+		reencodedPassword, err := rotated.Encode("password")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(reencodedPassword, "{k2}"))
+		assert.Equal(t, false, rotated.UpgradeEncoding(reencodedPassword))
+	})
+
+	t.Run("no key id", func(t *testing.T) {
+		assert.Equal(t, true, rotated.UpgradeEncoding("$2a$04$abc"))
+	})
+}
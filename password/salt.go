@@ -0,0 +1,29 @@
+package password
+
+import (
+	"fmt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+// MinSaltLength is the minimum salt length in bytes accepted by the salted encoders,
+// as KeyGenerators.secureRandom() in Spring
+const MinSaltLength = 8
+
+func mustValidSaltGen(saltGen keygen.BytesKeyGenerator) {
+	if saltGen.KeyLength() < MinSaltLength {
+		panic(fmt.Errorf("salt length %d of saltGen is less than MinSaltLength %d", saltGen.KeyLength(), MinSaltLength))
+	}
+}
+
+// generateSalt fails on keys shorter than saltGen.KeyLength(), e.g. a short read from the random source
+func generateSalt(saltGen keygen.BytesKeyGenerator) ([]byte, error) {
+	salt, err := saltGen.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(salt) != saltGen.KeyLength() {
+		return nil, fmt.Errorf("salt length %d, want %d", len(salt), saltGen.KeyLength())
+	}
+	return salt, nil
+}
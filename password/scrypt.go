@@ -2,6 +2,7 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"math"
 	"strconv"
@@ -21,30 +22,84 @@ type SCryptPasswordEncoder struct {
 	keyLen          int
 }
 
-var _ PasswordEncoder = (*SCryptPasswordEncoder)(nil)
+var _ ContextPasswordEncoder = (*SCryptPasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*SCryptPasswordEncoder)(nil)
 
+// SCryptPasswordEncoder.defaultsForSpringSecurity_v5_8()
 func DefaultSCryptPasswordEncoder() *SCryptPasswordEncoder {
 	saltLen := 16
 	saltGen := keygen.NewSecureRandomBytesKeyGenerator(saltLen)
+	return NewSCryptPasswordEncoder(saltGen, 65536, 8, 1, 32)
+}
+
+// panics if saltGen generates keys shorter than MinSaltLength
+func NewSCryptPasswordEncoder(saltGen keygen.BytesKeyGenerator, cpuCost, memoryCost, parallelization, keyLen int) *SCryptPasswordEncoder {
+	mustValidSaltGen(saltGen)
 	return &SCryptPasswordEncoder{
 		saltGen:         saltGen,
-		cpuCost:         65536,
-		memoryCost:      8,
-		parallelization: 1,
-		keyLen:          32,
+		cpuCost:         cpuCost,
+		memoryCost:      memoryCost,
+		parallelization: parallelization,
+		keyLen:          keyLen,
 	}
 }
 
 func (e *SCryptPasswordEncoder) Encode(rawPassword string) (string, error) {
-	salt, err := e.saltGen.GenerateKey()
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *SCryptPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *SCryptPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *SCryptPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *SCryptPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *SCryptPasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *SCryptPasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	salt, err := generateSalt(e.saltGen)
 	if err != nil {
 		return "", err
 	}
 
-	derived, err := scrypt.Key([]byte(rawPassword), salt, e.cpuCost, e.memoryCost, e.parallelization, e.keyLen)
+	derived, err := scrypt.Key(rawPassword, salt, e.cpuCost, e.memoryCost, e.parallelization, e.keyLen)
+	if err != nil {
+		return "", err
+	}
 	return e.encode(derived, salt), nil
 }
 
+func (e *SCryptPasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	cpuCost, memoryCost, parallelization, salt, derived, ok := e.decode(encodedPassword)
+	if !ok {
+		return false, nil
+	}
+
+	generated, err := scrypt.Key(rawPassword, salt, cpuCost, memoryCost, parallelization, e.keyLen)
+
+	return err == nil && bytes.Equal(derived, generated), nil
+}
+
 func (e *SCryptPasswordEncoder) encode(derived, salt []byte) string {
 	params := ((int)(math.Log2(float64(e.cpuCost))) << 16) | e.memoryCost<<8 | e.parallelization
 	var sb strings.Builder
@@ -63,37 +118,69 @@ func (e *SCryptPasswordEncoder) encodePart(part []byte) string {
 	return encoded
 }
 
-func (e *SCryptPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+// decode "$<hex params>$<base64 salt>$<base64 derived>"
+func (e *SCryptPasswordEncoder) decode(encodedPassword string) (cpuCost, memoryCost, parallelization int, salt, derived []byte, ok bool) {
 	parts := strings.Split(encodedPassword, "$")
 	if len(parts) != 4 { // ["", params, salt, derived]
-		return false
+		return 0, 0, 0, nil, nil, false
 	}
 
 	params, err := strconv.ParseInt(parts[1], 16, 64)
 	if err != nil {
-		return false
+		return 0, 0, 0, nil, nil, false
 	}
 
-	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	salt, err = base64.StdEncoding.DecodeString(parts[2])
 	if err != nil {
-		return false
+		return 0, 0, 0, nil, nil, false
 	}
 
-	derived, err := base64.StdEncoding.DecodeString(parts[3])
+	derived, err = base64.StdEncoding.DecodeString(parts[3])
 	if err != nil {
+		return 0, 0, 0, nil, nil, false
+	}
+
+	cpuCost = int(math.Pow(2, float64(params>>16&0xffff)))
+	memoryCost = int(params) >> 8 & 0xff
+	parallelization = int(params) & 0xff
+	return cpuCost, memoryCost, parallelization, salt, derived, true
+}
+
+// true if N, r, p or the key length are lower than configured
+func (e *SCryptPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	cpuCost, memoryCost, parallelization, _, derived, ok := e.decode(encodedPassword)
+	if !ok {
 		return false
 	}
+	return cpuCost < e.cpuCost || memoryCost < e.memoryCost || parallelization < e.parallelization || len(derived) < e.keyLen
+}
 
-	cpuCost := int(math.Pow(2, float64(params>>16&0xffff)))
-	memoryCost := int(params) >> 8 & 0xff
-	parallelization := int(params) & 0xff
+// EstimateMemory returns 128*r*(N+p+2) bytes, the size of scrypt's V, XY and B buffers
+func (e *SCryptPasswordEncoder) EstimateMemory(encodedPassword string) int64 {
+	if encodedPassword == "" {
+		return scryptMemory(e.cpuCost, e.memoryCost, e.parallelization)
+	}
+	cpuCost, memoryCost, parallelization, _, _, ok := e.decode(encodedPassword)
+	if !ok {
+		return 0
+	}
+	return scryptMemory(cpuCost, memoryCost, parallelization)
+}
 
-	generated, err := scrypt.Key([]byte(rawPassword), salt, cpuCost, memoryCost, parallelization, e.keyLen)
+func scryptMemory(cpuCost, memoryCost, parallelization int) int64 {
+	return 128 * int64(memoryCost) * (int64(cpuCost) + int64(parallelization) + 2)
+}
 
-	return bytes.Equal(derived, generated)
+func (e *SCryptPasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	info, err := inspectSCrypt(encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Strength = e.Strength()
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
 }
 
-func (e *SCryptPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
-	// TODO: compare cost
-	return false
+func (e *SCryptPasswordEncoder) Strength() Strength {
+	return StrengthStrong
 }
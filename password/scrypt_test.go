@@ -8,9 +8,22 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/xuyang2/password-encoder/keygen"
 	"github.com/xuyang2/password-encoder/keygen/keygentest"
 )
 
+func TestNewSCryptPasswordEncoder(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		assert.NotNil(t, NewSCryptPasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength), 16384, 8, 1, 32))
+	})
+
+	t.Run("panics salt too short", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewSCryptPasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength-1), 16384, 8, 1, 32)
+		})
+	})
+}
+
 func TestSCryptPasswordEncoder_Matches(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		encoder := DefaultSCryptPasswordEncoder()
@@ -61,10 +74,31 @@ func TestSCryptPasswordEncoder_Encode(t *testing.T) {
 		_, err := encoder.Encode("?")
 		assert.Error(t, err)
 	})
+
+	t.Run("golden", func(t *testing.T) {
+		encoder := NewSCryptPasswordEncoder(keygentest.FixedBytesKeyGenerator([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}), 16384, 8, 1, 32)
+		encodedPassword, err := encoder.Encode("password")
+		assert.NoError(t, err)
+		assert.Equal(t, "$e0801$AAECAwQFBgcICQoLDA0ODw==$6iMJXpgeItuXSS3ial5ceU6o+LQA0aKIA8ORmTlhNMU=", encodedPassword)
+	})
+
+	t.Run("fail after", func(t *testing.T) {
+		encoder := NewSCryptPasswordEncoder(keygentest.FailAfterBytesKeyGenerator(keygen.NewSecureRandomBytesKeyGenerator(16), 1, errors.New("oops")), 1024, 8, 1, 32)
+		_, err := encoder.Encode("?")
+		assert.NoError(t, err)
+		_, err = encoder.Encode("?")
+		assert.Error(t, err)
+	})
+
+	t.Run("err params", func(t *testing.T) {
+		encoder := NewSCryptPasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 1000, 8, 1, 32) // N must be a power of 2
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
 }
 
 func TestSCryptPasswordEncoder_UpgradeEncoding(t *testing.T) {
-	t.Run("always false", func(t *testing.T) {
+	t.Run("same params", func(t *testing.T) {
 		encoder := DefaultSCryptPasswordEncoder()
 
 		encodedPassword, err := encoder.Encode("password")
@@ -72,4 +106,18 @@ func TestSCryptPasswordEncoder_UpgradeEncoding(t *testing.T) {
 
 		assert.Equal(t, false, encoder.UpgradeEncoding(encodedPassword))
 	})
+
+	t.Run("lower params", func(t *testing.T) {
+		saltGen := keygen.NewSecureRandomBytesKeyGenerator(16)
+		encodedPassword, err := NewSCryptPasswordEncoder(saltGen, 16, 8, 1, 32).Encode("password")
+		require.NoError(t, err)
+
+		assert.Equal(t, false, NewSCryptPasswordEncoder(saltGen, 16, 8, 1, 32).UpgradeEncoding(encodedPassword))
+		assert.Equal(t, false, NewSCryptPasswordEncoder(saltGen, 8, 4, 1, 16).UpgradeEncoding(encodedPassword))
+		assert.Equal(t, true, NewSCryptPasswordEncoder(saltGen, 32, 8, 1, 32).UpgradeEncoding(encodedPassword))
+		assert.Equal(t, true, NewSCryptPasswordEncoder(saltGen, 16, 16, 1, 32).UpgradeEncoding(encodedPassword))
+		assert.Equal(t, true, NewSCryptPasswordEncoder(saltGen, 16, 8, 2, 32).UpgradeEncoding(encodedPassword))
+		assert.Equal(t, true, NewSCryptPasswordEncoder(saltGen, 16, 8, 1, 64).UpgradeEncoding(encodedPassword))
+		assert.Equal(t, false, NewSCryptPasswordEncoder(saltGen, 32, 8, 1, 32).UpgradeEncoding("$e0801$broken"))
+	})
 }
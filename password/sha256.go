@@ -2,6 +2,7 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 
@@ -13,15 +14,48 @@ type Sha256PasswordEncoder struct {
 	saltGen keygen.BytesKeyGenerator
 }
 
-var _ PasswordEncoder = (*Sha256PasswordEncoder)(nil)
+var _ ContextPasswordEncoder = (*Sha256PasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*Sha256PasswordEncoder)(nil)
 
 // Deprecated
+//
+// panics if saltGen generates keys shorter than MinSaltLength
 func NewSha256PasswordEncoder(saltGen keygen.BytesKeyGenerator) *Sha256PasswordEncoder {
+	mustValidSaltGen(saltGen)
 	return &Sha256PasswordEncoder{saltGen: saltGen}
 }
 
 func (e *Sha256PasswordEncoder) Encode(rawPassword string) (string, error) {
-	salt, err := e.saltGen.GenerateKey()
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *Sha256PasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *Sha256PasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *Sha256PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Sha256PasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *Sha256PasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Sha256PasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	salt, err := generateSalt(e.saltGen)
 	if err != nil {
 		return "", err
 	}
@@ -29,32 +63,51 @@ func (e *Sha256PasswordEncoder) Encode(rawPassword string) (string, error) {
 	return hex.EncodeToString(digest), nil
 }
 
-func (e *Sha256PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+func (e *Sha256PasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
 	digested, err := hex.DecodeString(encodedPassword)
 	if err != nil {
-		return false
+		return false, nil
 	}
 
 	if len(digested) < sha256.Size {
-		return false
+		return false, nil
 	}
 	salt := make([]byte, len(digested)-sha256.Size)
 	copy(salt, digested[0:len(digested)-sha256.Size])
 
-	return bytes.Equal(digested, e.digest(rawPassword, salt))
+	return bytes.Equal(digested, e.digest(rawPassword, salt)), nil
 }
 
-// return salt + sha1(salt + rawPassword)
-func (e *Sha256PasswordEncoder) digest(rawPassword string, salt []byte) []byte {
-	var bb bytes.Buffer
-	bb.Write(salt)
-	bb.Write([]byte(rawPassword))
-	saltPassword := bb.Bytes()
+// return salt + sha256(salt + rawPassword), zeroing the concatenation
+func (e *Sha256PasswordEncoder) digest(rawPassword []byte, salt []byte) []byte {
+	saltPassword := make([]byte, 0, len(salt)+len(rawPassword))
+	saltPassword = append(append(saltPassword, salt...), rawPassword...)
+	defer zero(saltPassword)
+
 	digest := sha256.Sum256(saltPassword)
-	saltDigest := append(salt[:], digest[:]...)
-	return saltDigest
+	saltDigest := make([]byte, 0, len(salt)+len(digest))
+	return append(append(saltDigest, salt...), digest[:]...)
 }
 
+// always true, single-pass digests are too fast for password storage
 func (e *Sha256PasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
-	return false
+	return true
+}
+
+func (e *Sha256PasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	info, err := inspectHex(encodedPassword, sha256.Size)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Algorithm = AlgorithmSha256
+	info.Strength = e.Strength()
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+func (e *Sha256PasswordEncoder) Strength() Strength {
+	return StrengthLegacy
 }
@@ -12,6 +12,18 @@ import (
 	"github.com/xuyang2/password-encoder/keygen/keygentest"
 )
 
+func TestNewSha256PasswordEncoder(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		assert.NotNil(t, NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength)))
+	})
+
+	t.Run("panics salt too short", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength - 1))
+		})
+	})
+}
+
 func TestSha256PasswordEncoder_Matches(t *testing.T) {
 	encoder := Sha256PasswordEncoder{
 		saltGen: keygen.NewSecureRandomBytesKeyGenerator(16),
@@ -42,10 +54,27 @@ func TestSha256PasswordEncoder_Encode(t *testing.T) {
 		_, err := encoder.Encode("?")
 		assert.Error(t, err)
 	})
+
+	t.Run("golden", func(t *testing.T) {
+		encoder := Sha256PasswordEncoder{
+			saltGen: keygentest.FixedBytesKeyGenerator([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}),
+		}
+		encodedPassword, err := encoder.Encode("password")
+		assert.NoError(t, err)
+		assert.Equal(t, "000102030405060708090a0b0c0d0e0f04a89147600cc2b5eb582ea76c73c85443f77b9fd178e69e3e4f15f6b9759682", encodedPassword)
+	})
+
+	t.Run("short salt", func(t *testing.T) {
+		encoder := Sha256PasswordEncoder{
+			saltGen: keygentest.ShortBytesKeyGenerator(keygen.NewSecureRandomBytesKeyGenerator(16), 15),
+		}
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
 }
 
 func TestSha256PasswordEncoder_UpgradeEncoding(t *testing.T) {
-	t.Run("always false", func(t *testing.T) {
+	t.Run("always true", func(t *testing.T) {
 		encoder := Sha256PasswordEncoder{
 			saltGen: keygen.NewSecureRandomBytesKeyGenerator(16),
 		}
@@ -53,6 +82,6 @@ func TestSha256PasswordEncoder_UpgradeEncoding(t *testing.T) {
 		encodedPassword, err := encoder.Encode("password")
 		require.NoError(t, err)
 
-		assert.Equal(t, false, encoder.UpgradeEncoding(encodedPassword))
+		assert.Equal(t, true, encoder.UpgradeEncoding(encodedPassword))
 	})
 }
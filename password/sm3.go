@@ -2,6 +2,7 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 
 	"github.com/emmansun/gmsm/sm3"
@@ -13,14 +14,46 @@ type Sm3PasswordEncoder struct {
 	saltGen keygen.BytesKeyGenerator
 }
 
+// panics if saltGen generates keys shorter than MinSaltLength
 func NewSm3PasswordEncoder(saltGen keygen.BytesKeyGenerator) *Sm3PasswordEncoder {
+	mustValidSaltGen(saltGen)
 	return &Sm3PasswordEncoder{saltGen: saltGen}
 }
 
-var _ PasswordEncoder = (*Sm3PasswordEncoder)(nil)
+var _ ContextPasswordEncoder = (*Sm3PasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*Sm3PasswordEncoder)(nil)
 
 func (e *Sm3PasswordEncoder) Encode(rawPassword string) (string, error) {
-	salt, err := e.saltGen.GenerateKey()
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *Sm3PasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *Sm3PasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *Sm3PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Sm3PasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *Sm3PasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Sm3PasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	salt, err := generateSalt(e.saltGen)
 	if err != nil {
 		return "", err
 	}
@@ -28,32 +61,51 @@ func (e *Sm3PasswordEncoder) Encode(rawPassword string) (string, error) {
 	return hex.EncodeToString(digest), nil
 }
 
-func (e *Sm3PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+func (e *Sm3PasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
 	digested, err := hex.DecodeString(encodedPassword)
 	if err != nil {
-		return false
+		return false, nil
 	}
 
 	if len(digested) < sm3.Size {
-		return false
+		return false, nil
 	}
 	salt := make([]byte, len(digested)-sm3.Size)
 	copy(salt, digested[0:len(digested)-sm3.Size])
 
-	return bytes.Equal(digested, e.digest(rawPassword, salt))
+	return bytes.Equal(digested, e.digest(rawPassword, salt)), nil
 }
 
-// return salt + sm3(salt + rawPassword)
-func (e *Sm3PasswordEncoder) digest(rawPassword string, salt []byte) []byte {
-	var bb bytes.Buffer
-	bb.Write(salt)
-	bb.Write([]byte(rawPassword))
-	saltPassword := bb.Bytes()
+// return salt + sm3(salt + rawPassword), zeroing the concatenation
+func (e *Sm3PasswordEncoder) digest(rawPassword []byte, salt []byte) []byte {
+	saltPassword := make([]byte, 0, len(salt)+len(rawPassword))
+	saltPassword = append(append(saltPassword, salt...), rawPassword...)
+	defer zero(saltPassword)
+
 	digest := sm3.Sum(saltPassword)
-	saltDigest := append(salt[:], digest[:]...)
-	return saltDigest
+	saltDigest := make([]byte, 0, len(salt)+len(digest))
+	return append(append(saltDigest, salt...), digest[:]...)
 }
 
+// always true, single-pass digests are too fast for password storage
 func (e *Sm3PasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
-	return false
+	return true
+}
+
+func (e *Sm3PasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	info, err := inspectHex(encodedPassword, sm3.Size)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Algorithm = AlgorithmSm3
+	info.Strength = e.Strength()
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+func (e *Sm3PasswordEncoder) Strength() Strength {
+	return StrengthLegacy
 }
@@ -12,6 +12,18 @@ import (
 	"github.com/xuyang2/password-encoder/keygen/keygentest"
 )
 
+func TestNewSm3PasswordEncoder(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		assert.NotNil(t, NewSm3PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength)))
+	})
+
+	t.Run("panics salt too short", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewSm3PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength - 1))
+		})
+	})
+}
+
 func TestSm3PasswordEncoder_Matches(t *testing.T) {
 	encoder := Sm3PasswordEncoder{
 		saltGen: keygen.NewSecureRandomBytesKeyGenerator(16),
@@ -43,10 +55,37 @@ func TestSm3PasswordEncoder_Encode(t *testing.T) {
 		_, err := encoder.Encode("?")
 		assert.Error(t, err)
 	})
+
+	t.Run("golden", func(t *testing.T) {
+		encoder := Sm3PasswordEncoder{
+			saltGen: keygentest.FixedBytesKeyGenerator([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}),
+		}
+		encodedPassword, err := encoder.Encode("password")
+		assert.NoError(t, err)
+		assert.Equal(t, "000102030405060708090a0b0c0d0e0f962964e778ea31de1bee523cae8990ada9bb2e6d8c6f9cd015d3538b327041f2", encodedPassword)
+	})
+
+	t.Run("drbg saltGen", func(t *testing.T) {
+		saltGen, err := keygen.NewSm3DrbgBytesKeyGenerator(16, keygen.DrbgConfig{})
+		require.NoError(t, err)
+		encoder := NewSm3PasswordEncoder(saltGen)
+
+		encodedPassword, err := encoder.Encode("password")
+		assert.NoError(t, err)
+		assert.True(t, encoder.Matches("password", encodedPassword))
+	})
+
+	t.Run("short salt", func(t *testing.T) {
+		encoder := Sm3PasswordEncoder{
+			saltGen: keygentest.ShortBytesKeyGenerator(keygen.NewSecureRandomBytesKeyGenerator(16), 15),
+		}
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
 }
 
 func TestSm3PasswordEncoder_UpgradeEncoding(t *testing.T) {
-	t.Run("always false", func(t *testing.T) {
+	t.Run("always true", func(t *testing.T) {
 		encoder := Sm3PasswordEncoder{
 			saltGen: keygen.NewSecureRandomBytesKeyGenerator(16),
 		}
@@ -54,6 +93,6 @@ func TestSm3PasswordEncoder_UpgradeEncoding(t *testing.T) {
 		encodedPassword, err := encoder.Encode("password")
 		require.NoError(t, err)
 
-		assert.Equal(t, false, encoder.UpgradeEncoding(encodedPassword))
+		assert.Equal(t, true, encoder.UpgradeEncoding(encodedPassword))
 	})
 }
@@ -0,0 +1,178 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/emmansun/gmsm/sm3"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+const sm3Pbkdf2Prefix = "$pbkdf2-sm3$"
+
+// Sm3Pbkdf2PasswordEncoder is PBKDF2-HMAC-SM3 with configurable iterations.
+//
+// The encoded password is self-describing, in PHC string format:
+// "$pbkdf2-sm3$i=<iterations>$<base64 salt>$<base64 key>".
+//
+// Legacy single-pass Sm3PasswordEncoder hex output keeps verifying,
+// UpgradeEncoding returns true for it.
+type Sm3Pbkdf2PasswordEncoder struct {
+	saltGen keygen.BytesKeyGenerator
+	iter    int
+	keyLen  int
+
+	legacy *Sm3PasswordEncoder
+}
+
+var _ ContextPasswordEncoder = (*Sm3Pbkdf2PasswordEncoder)(nil)
+var _ BytesPasswordEncoder = (*Sm3Pbkdf2PasswordEncoder)(nil)
+
+// OWASP recommends 600000 iterations for PBKDF2-HMAC-SHA256, SM3 has the same block and digest size
+func DefaultSm3Pbkdf2PasswordEncoder() *Sm3Pbkdf2PasswordEncoder {
+	saltLen := 16
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(saltLen)
+	return NewSm3Pbkdf2PasswordEncoder(saltGen, 600000, sm3.Size)
+}
+
+// panics if saltGen generates keys shorter than MinSaltLength
+func NewSm3Pbkdf2PasswordEncoder(saltGen keygen.BytesKeyGenerator, iter int, keyLen int) *Sm3Pbkdf2PasswordEncoder {
+	mustValidSaltGen(saltGen)
+	return &Sm3Pbkdf2PasswordEncoder{
+		saltGen: saltGen,
+		iter:    iter,
+		keyLen:  keyLen,
+		legacy:  &Sm3PasswordEncoder{saltGen: saltGen},
+	}
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeContext(context.Background(), rawPassword)
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return encodeStringContext(ctx, e, rawPassword)
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	return e.encodeBytesContext(context.Background(), rawPassword)
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	matched, _ := e.MatchesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return matchesStringContext(ctx, e, rawPassword, encodedPassword)
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	matched, _ := e.matchesBytesContext(context.Background(), rawPassword, encodedPassword)
+	return matched
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) encodeBytesContext(ctx context.Context, rawPassword []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	salt, err := generateSalt(e.saltGen)
+	if err != nil {
+		return "", err
+	}
+	key, err := pbkdf2Key(ctx, rawPassword, salt, e.iter, e.keyLen, sm3.New)
+	if err != nil {
+		return "", err
+	}
+	return e.encode(e.iter, salt, key), nil
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) matchesBytesContext(ctx context.Context, rawPassword []byte, encodedPassword string) (bool, error) {
+	if !strings.HasPrefix(encodedPassword, sm3Pbkdf2Prefix) {
+		return e.legacy.matchesBytesContext(ctx, rawPassword, encodedPassword)
+	}
+
+	iter, salt, key, ok := e.decode(encodedPassword)
+	if !ok {
+		return false, nil
+	}
+	generated, err := pbkdf2Key(ctx, rawPassword, salt, iter, len(key), sm3.New)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(key, generated), nil
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) encode(iter int, salt, key []byte) string {
+	var sb strings.Builder
+	sb.WriteString(sm3Pbkdf2Prefix)
+	sb.WriteString("i=")
+	sb.WriteString(strconv.Itoa(iter))
+	sb.WriteString("$")
+	sb.WriteString(base64.RawStdEncoding.EncodeToString(salt))
+	sb.WriteString("$")
+	sb.WriteString(base64.RawStdEncoding.EncodeToString(key))
+	return sb.String()
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	if !strings.HasPrefix(encodedPassword, sm3Pbkdf2Prefix) {
+		return true // legacy Sm3PasswordEncoder
+	}
+
+	iter, salt, key, ok := e.decode(encodedPassword)
+	if !ok {
+		return false
+	}
+	return iter < e.iter || len(salt) < e.saltGen.KeyLength() || len(key) < e.keyLen
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) Inspect(encodedPassword string) (HashInfo, error) {
+	if !strings.HasPrefix(encodedPassword, sm3Pbkdf2Prefix) {
+		return e.legacy.Inspect(encodedPassword) // UpgradeEncoding is always true
+	}
+
+	info, err := inspectSm3Pbkdf2(encodedPassword)
+	if err != nil {
+		return HashInfo{}, err
+	}
+	info.Strength = e.Strength()
+	info.UpgradeEncoding = e.UpgradeEncoding(encodedPassword)
+	return info, nil
+}
+
+func (e *Sm3Pbkdf2PasswordEncoder) Strength() Strength {
+	return StrengthStrong
+}
+
+// decode "$pbkdf2-sm3$i=<iterations>$<base64 salt>$<base64 key>"
+func (e *Sm3Pbkdf2PasswordEncoder) decode(encodedPassword string) (iter int, salt, key []byte, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(encodedPassword, sm3Pbkdf2Prefix), "$")
+	if len(parts) != 3 { // [params, salt, key]
+		return 0, nil, nil, false
+	}
+
+	if !strings.HasPrefix(parts[0], "i=") {
+		return 0, nil, nil, false
+	}
+	iter, err := strconv.Atoi(strings.TrimPrefix(parts[0], "i="))
+	if err != nil || iter < 1 {
+		return 0, nil, nil, false
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, nil, false
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(key) == 0 {
+		return 0, nil, nil, false
+	}
+
+	return iter, salt, key, true
+}
@@ -0,0 +1,129 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/keygen/keygentest"
+)
+
+func TestNewSm3Pbkdf2PasswordEncoder(t *testing.T) {
+	t.Run("panics salt too short", func(t *testing.T) {
+		assert.Panics(t, func() {
+			NewSm3Pbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(MinSaltLength-1), 1000, 32)
+		})
+	})
+}
+
+func TestSm3Pbkdf2PasswordEncoder_Matches(t *testing.T) {
+	encoder := NewSm3Pbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 1000, 32)
+
+	t.Run("ok", func(t *testing.T) {
+		rawPassword := "password"
+		encodedPassword, err := encoder.Encode(rawPassword)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encodedPassword, "$pbkdf2-sm3$i=1000$"))
+		assert.True(t, encoder.Matches(rawPassword, encodedPassword))
+		assert.False(t, encoder.Matches(rawPassword+"a", encodedPassword))
+
+		parts := strings.Split(encodedPassword, "$")
+		assert.Len(t, parts, 5)
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], "_", parts[3], parts[4]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], "i=0", parts[3], parts[4]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], "i=x", parts[3], parts[4]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], "_", parts[4]}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], parts[3], "_"}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], parts[3], ""}, "$")))
+		assert.False(t, encoder.Matches(rawPassword, strings.Join([]string{"", parts[1], parts[2], parts[3]}, "$")))
+
+		assert.False(t, encoder.Matches(rawPassword, ""))
+	})
+
+	t.Run("golden", func(t *testing.T) {
+		// OpenSSL: hashlib.pbkdf2_hmac("sm3", b"password", bytes(range(16)), 1000, 32)
+		encodedPassword := "$pbkdf2-sm3$i=1000$AAECAwQFBgcICQoLDA0ODw$MEwyS1xzWE068inyJLnAwqfoVBrZnpdtRhghJfOEPw0"
+		assert.True(t, encoder.Matches("password", encodedPassword))
+		assert.False(t, encoder.Matches("password1", encodedPassword))
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		legacyEncodedPassword, err := NewSm3PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16)).Encode("password")
+		require.NoError(t, err)
+
+		assert.True(t, encoder.Matches("password", legacyEncodedPassword))
+		assert.False(t, encoder.Matches("password1", legacyEncodedPassword))
+	})
+}
+
+func TestSm3Pbkdf2PasswordEncoder_Encode(t *testing.T) {
+	t.Run("golden", func(t *testing.T) {
+		encoder := NewSm3Pbkdf2PasswordEncoder(keygentest.FixedBytesKeyGenerator([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}), 1, 32)
+
+		// OpenSSL: hashlib.pbkdf2_hmac("sm3", b"password", bytes(range(16)), 1, 32)
+		encodedPassword, err := encoder.Encode("password")
+		assert.NoError(t, err)
+		assert.Equal(t, "$pbkdf2-sm3$i=1$AAECAwQFBgcICQoLDA0ODw$MXUWg5DkuZbL1ISdIKis4IDE490WugtoVpYJ8oWodcI", encodedPassword)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		encoder := DefaultSm3Pbkdf2PasswordEncoder()
+		encodedPassword, err := encoder.Encode("password")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encodedPassword, "$pbkdf2-sm3$i=600000$"))
+	})
+
+	t.Run("err saltGen", func(t *testing.T) {
+		encoder := NewSm3Pbkdf2PasswordEncoder(keygentest.ErrBytesKeyGenerator(errors.New("oops"), 16), 1000, 32)
+		_, err := encoder.Encode("?")
+		assert.Error(t, err)
+	})
+}
+
+func TestSm3Pbkdf2PasswordEncoder_UpgradeEncoding(t *testing.T) {
+	encoder := NewSm3Pbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 1000, 32)
+
+	t.Run("not UpgradeEncoding", func(t *testing.T) {
+		encodedPassword, err := encoder.Encode("password")
+		require.NoError(t, err)
+
+		assert.Equal(t, false, encoder.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		legacyEncodedPassword, err := NewSm3PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16)).Encode("password")
+		require.NoError(t, err)
+
+		assert.Equal(t, true, encoder.UpgradeEncoding(legacyEncodedPassword))
+	})
+
+	t.Run("fewer iterations", func(t *testing.T) {
+		encodedPassword, err := NewSm3Pbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 999, 32).Encode("password")
+		require.NoError(t, err)
+
+		assert.Equal(t, true, encoder.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("shorter salt", func(t *testing.T) {
+		encodedPassword, err := NewSm3Pbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(8), 1000, 32).Encode("password")
+		require.NoError(t, err)
+
+		assert.Equal(t, true, encoder.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("shorter key", func(t *testing.T) {
+		encodedPassword, err := NewSm3Pbkdf2PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16), 1000, 16).Encode("password")
+		require.NoError(t, err)
+
+		assert.Equal(t, true, encoder.UpgradeEncoding(encodedPassword))
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		assert.Equal(t, false, encoder.UpgradeEncoding("$pbkdf2-sm3$_"))
+	})
+}
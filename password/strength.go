@@ -0,0 +1,41 @@
+package password
+
+// Strength classifies how well a PasswordEncoder resists offline guessing.
+type Strength int
+
+const (
+	StrengthUnknown  Strength = iota // not classified
+	StrengthInsecure                 // plaintext, e.g. NopPasswordEncoder
+	StrengthLegacy                   // fast salted digest, e.g. Sha256PasswordEncoder
+	StrengthStrong                   // adaptive one-way function, e.g. BCryptPasswordEncoder
+)
+
+func (s Strength) String() string {
+	switch s {
+	case StrengthInsecure:
+		return "insecure"
+	case StrengthLegacy:
+		return "legacy"
+	case StrengthStrong:
+		return "strong"
+	default:
+		return "unknown"
+	}
+}
+
+func (s Strength) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// StrengthClassifier is implemented by encoders that classify themselves.
+type StrengthClassifier interface {
+	Strength() Strength
+}
+
+// StrengthOf returns the Strength of encoder, StrengthUnknown if it does not classify itself.
+func StrengthOf(encoder PasswordEncoder) Strength {
+	if c, ok := encoder.(StrengthClassifier); ok {
+		return c.Strength()
+	}
+	return StrengthUnknown
+}
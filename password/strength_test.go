@@ -0,0 +1,53 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+type classifiedPasswordEncoder struct {
+	PasswordEncoder
+	strength Strength
+}
+
+func (e *classifiedPasswordEncoder) Strength() Strength {
+	return e.strength
+}
+
+func TestStrength_String(t *testing.T) {
+	assert.Equal(t, "unknown", StrengthUnknown.String())
+	assert.Equal(t, "insecure", StrengthInsecure.String())
+	assert.Equal(t, "legacy", StrengthLegacy.String())
+	assert.Equal(t, "strong", StrengthStrong.String())
+	assert.Equal(t, "unknown", Strength(42).String())
+}
+
+func TestStrengthOf(t *testing.T) {
+	saltGen := keygen.NewSecureRandomBytesKeyGenerator(16)
+	bcryptEncoder := NewBCryptPasswordEncoder(bcrypt.MinCost)
+
+	tests := []struct {
+		name    string
+		encoder PasswordEncoder
+		want    Strength
+	}{
+		{name: "noop", encoder: NopPasswordEncoder(), want: StrengthInsecure},
+		{name: "sha256", encoder: NewSha256PasswordEncoder(saltGen), want: StrengthLegacy},
+		{name: "sm3", encoder: NewSm3PasswordEncoder(saltGen), want: StrengthLegacy},
+		{name: "bcrypt", encoder: bcryptEncoder, want: StrengthStrong},
+		{name: "pbkdf2", encoder: DefaultPbkdf2PasswordEncoder(), want: StrengthStrong},
+		{name: "scrypt", encoder: DefaultSCryptPasswordEncoder(), want: StrengthStrong},
+		{name: "sm3-pbkdf2", encoder: DefaultSm3Pbkdf2PasswordEncoder(), want: StrengthStrong},
+		{name: "pepper", encoder: NewPepperPasswordEncoder(NewSha256PasswordEncoder(saltGen), "k1", map[string][]byte{"k1": []byte("secret")}), want: StrengthLegacy},
+		{name: "unknown", encoder: &errEncodePasswordEncoder{}, want: StrengthUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, StrengthOf(tt.encoder))
+		})
+	}
+}
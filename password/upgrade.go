@@ -0,0 +1,72 @@
+package password
+
+import "fmt"
+
+// PasswordUpgrader persists the re-encoded password after a successful match,
+// like Spring's UserDetailsPasswordService.updatePassword.
+//
+// https://docs.spring.io/spring-security/site/docs/5.6.0/api/org/springframework/security/core/userdetails/UserDetailsPasswordService.html
+type PasswordUpgrader interface {
+	UpdatePassword(encodedPassword string, newEncodedPassword string) error
+}
+
+type PasswordUpgraderFunc func(encodedPassword string, newEncodedPassword string) error
+
+func (f PasswordUpgraderFunc) UpdatePassword(encodedPassword string, newEncodedPassword string) error {
+	return f(encodedPassword, newEncodedPassword)
+}
+
+// VerifiedEncoder is a PasswordEncoder whose Encode checks raw passwords, e.g. against a password policy
+// or compromised passwords. EncodeVerified encodes a raw password that matched an encoded password of the encoder
+// without those checks, since the user cannot choose another password at login.
+type VerifiedEncoder interface {
+	PasswordEncoder
+
+	EncodeVerified(rawPassword string) (string, error)
+}
+
+// EncodeVerified calls encoder.EncodeVerified if encoder is a VerifiedEncoder, otherwise encoder.Encode.
+// rawPassword must have matched an encoded password of encoder.
+func EncodeVerified(encoder PasswordEncoder, rawPassword string) (string, error) {
+	if e, ok := encoder.(VerifiedEncoder); ok {
+		return e.EncodeVerified(rawPassword)
+	}
+	return encoder.Encode(rawPassword)
+}
+
+// MatchAndUpgrade checks rawPassword against encodedPassword and, if it matches and
+// encoder.UpgradeEncoding(encodedPassword), re-encodes rawPassword with EncodeVerified.
+//
+// newEncodedPassword is the value to persist, empty if no upgrade is needed.
+// If re-encoding fails the login still succeeds: matched is true, newEncodedPassword
+// is empty and err reports the skipped upgrade.
+func MatchAndUpgrade(encoder PasswordEncoder, rawPassword string, encodedPassword string) (matched bool, newEncodedPassword string, err error) {
+	if !encoder.Matches(rawPassword, encodedPassword) {
+		return false, "", nil
+	}
+
+	if !encoder.UpgradeEncoding(encodedPassword) {
+		return true, "", nil
+	}
+
+	newEncodedPassword, err = EncodeVerified(encoder, rawPassword)
+	if err != nil {
+		return true, "", fmt.Errorf("password upgrade skipped: %w", err)
+	}
+	return true, newEncodedPassword, nil
+}
+
+// MatchAndUpgradeWith is MatchAndUpgrade, then upgrader.UpdatePassword with the re-encoded password.
+//
+// matched is true even if the upgrade is skipped or fails to persist, err reports it.
+func MatchAndUpgradeWith(encoder PasswordEncoder, rawPassword string, encodedPassword string, upgrader PasswordUpgrader) (matched bool, err error) {
+	matched, newEncodedPassword, err := MatchAndUpgrade(encoder, rawPassword, encodedPassword)
+	if !matched || err != nil || newEncodedPassword == "" {
+		return matched, err
+	}
+
+	if err := upgrader.UpdatePassword(encodedPassword, newEncodedPassword); err != nil {
+		return true, fmt.Errorf("password upgrade not persisted: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,165 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+)
+
+func newUpgradeTestEncoder() *DelegatingPasswordEncoder {
+	return NewDelegatingPasswordEncoder("bcrypt", map[string]PasswordEncoder{
+		"bcrypt": NewBCryptPasswordEncoder(bcrypt.MinCost),
+		"sha256": NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16)),
+	})
+}
+
+func TestMatchAndUpgrade(t *testing.T) {
+	encoder := newUpgradeTestEncoder()
+
+	t.Run("upgrade", func(t *testing.T) {
+		matched, newEncodedPassword, err := MatchAndUpgrade(encoder, "password", "{noop}password")
+		assert.False(t, matched) // noop not mapped
+		assert.NoError(t, err)
+		assert.Empty(t, newEncodedPassword)
+
+		legacy, err := encoder.idToPasswordEncoder["sha256"].Encode("password")
+		require.NoError(t, err)
+
+		matched, newEncodedPassword, err = MatchAndUpgrade(encoder, "password", "{sha256}"+legacy)
+		assert.True(t, matched)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(newEncodedPassword, "{bcrypt}"))
+		assert.True(t, encoder.Matches("password", newEncodedPassword))
+	})
+
+	t.Run("no upgrade", func(t *testing.T) {
+		encodedPassword, err := encoder.Encode("password")
+		require.NoError(t, err)
+
+		matched, newEncodedPassword, err := MatchAndUpgrade(encoder, "password", encodedPassword)
+		assert.True(t, matched)
+		assert.NoError(t, err)
+		assert.Empty(t, newEncodedPassword)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		encodedPassword, err := encoder.Encode("password")
+		require.NoError(t, err)
+
+		matched, newEncodedPassword, err := MatchAndUpgrade(encoder, "password1", encodedPassword)
+		assert.False(t, matched)
+		assert.NoError(t, err)
+		assert.Empty(t, newEncodedPassword)
+	})
+
+	t.Run("encode err", func(t *testing.T) {
+		wtf := errors.New("WTF")
+		encoder := &errEncodePasswordEncoder{PasswordEncoder: NopPasswordEncoder(), err: wtf}
+
+		matched, newEncodedPassword, err := MatchAndUpgrade(encoder, "password", "password")
+		assert.True(t, matched)
+		assert.ErrorIs(t, err, wtf)
+		assert.Empty(t, newEncodedPassword)
+	})
+
+	t.Run("verified", func(t *testing.T) {
+		delegating := newUpgradeTestEncoder()
+		encoder := verifiedPasswordEncoder{&errEncodePasswordEncoder{PasswordEncoder: delegating, err: errors.New("WTF")}}
+		legacy, err := delegating.idToPasswordEncoder["sha256"].Encode("password")
+		require.NoError(t, err)
+
+		matched, newEncodedPassword, err := MatchAndUpgrade(encoder, "password", "{sha256}"+legacy)
+		assert.True(t, matched)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(newEncodedPassword, "{bcrypt}"))
+	})
+}
+
+// a VerifiedEncoder whose Encode fails, as a failing password policy
+type verifiedPasswordEncoder struct {
+	*errEncodePasswordEncoder
+}
+
+func (e verifiedPasswordEncoder) EncodeVerified(rawPassword string) (string, error) {
+	return e.PasswordEncoder.Encode(rawPassword)
+}
+
+func TestEncodeVerified(t *testing.T) {
+	encoder := &errEncodePasswordEncoder{PasswordEncoder: NopPasswordEncoder(), err: errors.New("WTF")}
+
+	_, err := EncodeVerified(encoder, "password")
+	assert.Error(t, err)
+
+	encodedPassword, err := EncodeVerified(verifiedPasswordEncoder{encoder}, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, "password", encodedPassword)
+}
+
+func TestMatchAndUpgradeWith(t *testing.T) {
+	encoder := newUpgradeTestEncoder()
+
+	legacy, err := encoder.idToPasswordEncoder["sha256"].Encode("password")
+	require.NoError(t, err)
+	encodedPassword := "{sha256}" + legacy
+
+	t.Run("upgrade", func(t *testing.T) {
+		var stored string
+		matched, err := MatchAndUpgradeWith(encoder, "password", encodedPassword, PasswordUpgraderFunc(func(old, new string) error {
+			assert.Equal(t, encodedPassword, old)
+			stored = new
+			return nil
+		}))
+		assert.True(t, matched)
+		assert.NoError(t, err)
+		assert.True(t, encoder.Matches("password", stored))
+		assert.False(t, encoder.UpgradeEncoding(stored))
+	})
+
+	t.Run("no upgrade", func(t *testing.T) {
+		bcryptEncodedPassword, err := encoder.Encode("password")
+		require.NoError(t, err)
+
+		matched, err := MatchAndUpgradeWith(encoder, "password", bcryptEncodedPassword, PasswordUpgraderFunc(func(old, new string) error {
+			t.Fatal("unexpected UpdatePassword")
+			return nil
+		}))
+		assert.True(t, matched)
+		assert.NoError(t, err)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		matched, err := MatchAndUpgradeWith(encoder, "password1", encodedPassword, PasswordUpgraderFunc(func(old, new string) error {
+			t.Fatal("unexpected UpdatePassword")
+			return nil
+		}))
+		assert.False(t, matched)
+		assert.NoError(t, err)
+	})
+
+	t.Run("update err", func(t *testing.T) {
+		wtf := errors.New("WTF")
+		matched, err := MatchAndUpgradeWith(encoder, "password", encodedPassword, PasswordUpgraderFunc(func(old, new string) error {
+			return wtf
+		}))
+		assert.True(t, matched)
+		assert.ErrorIs(t, err, wtf)
+	})
+
+	t.Run("encode err", func(t *testing.T) {
+		wtf := errors.New("WTF")
+		encoder := &errEncodePasswordEncoder{PasswordEncoder: NopPasswordEncoder(), err: wtf}
+
+		matched, err := MatchAndUpgradeWith(encoder, "password", "password", PasswordUpgraderFunc(func(old, new string) error {
+			t.Fatal("unexpected UpdatePassword")
+			return nil
+		}))
+		assert.True(t, matched)
+		assert.ErrorIs(t, err, wtf)
+	})
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"context"
+
+	"github.com/xuyang2/password-encoder/password"
+)
+
+// ValidatingPasswordEncoder refuses to encode raw passwords violating a Policy before delegating to another PasswordEncoder,
+// returning a *ViolationError. Matches does not check the policy, so that existing passwords keep verifying,
+// and neither does EncodeVerified, so that password.MatchAndUpgrade upgrades their encoding.
+//
+// Encode checks the raw password alone, EncodeInput also checks the user info, e.g. the username.
+type ValidatingPasswordEncoder struct {
+	delegate password.PasswordEncoder
+	policy   *Policy
+}
+
+var _ password.ContextPasswordEncoder = (*ValidatingPasswordEncoder)(nil)
+var _ password.BytesPasswordEncoder = (*ValidatingPasswordEncoder)(nil)
+var _ password.VerifiedEncoder = (*ValidatingPasswordEncoder)(nil)
+
+func NewValidatingPasswordEncoder(delegate password.PasswordEncoder, policy *Policy) *ValidatingPasswordEncoder {
+	return &ValidatingPasswordEncoder{
+		delegate: delegate,
+		policy:   policy,
+	}
+}
+
+func (e *ValidatingPasswordEncoder) Encode(rawPassword string) (string, error) {
+	return e.EncodeInput(context.Background(), Input{Password: rawPassword})
+}
+
+func (e *ValidatingPasswordEncoder) EncodeContext(ctx context.Context, rawPassword string) (string, error) {
+	return e.EncodeInput(ctx, Input{Password: rawPassword})
+}
+
+// EncodeBytes validates a string copy of rawPassword, which cannot be zeroed
+func (e *ValidatingPasswordEncoder) EncodeBytes(rawPassword []byte) (string, error) {
+	if err := e.policy.Validate(Input{Password: string(rawPassword)}); err != nil {
+		return "", err
+	}
+	return password.EncodeBytes(e.delegate, rawPassword)
+}
+
+// EncodeInput encodes in.Password if it does not violate the policy
+func (e *ValidatingPasswordEncoder) EncodeInput(ctx context.Context, in Input) (string, error) {
+	if err := e.policy.Validate(in); err != nil {
+		return "", err
+	}
+	return password.EncodeContext(ctx, e.delegate, in.Password)
+}
+
+// EncodeVerified encodes rawPassword without checking the policy, see password.VerifiedEncoder
+func (e *ValidatingPasswordEncoder) EncodeVerified(rawPassword string) (string, error) {
+	return password.EncodeVerified(e.delegate, rawPassword)
+}
+
+func (e *ValidatingPasswordEncoder) Matches(rawPassword string, encodedPassword string) bool {
+	return e.delegate.Matches(rawPassword, encodedPassword)
+}
+
+func (e *ValidatingPasswordEncoder) MatchesContext(ctx context.Context, rawPassword string, encodedPassword string) (bool, error) {
+	return password.MatchesContext(ctx, e.delegate, rawPassword, encodedPassword)
+}
+
+func (e *ValidatingPasswordEncoder) MatchesBytes(rawPassword []byte, encodedPassword string) bool {
+	return password.MatchesBytes(e.delegate, rawPassword, encodedPassword)
+}
+
+func (e *ValidatingPasswordEncoder) UpgradeEncoding(encodedPassword string) bool {
+	return e.delegate.UpgradeEncoding(encodedPassword)
+}
+
+// the Strength of the delegate
+func (e *ValidatingPasswordEncoder) Strength() password.Strength {
+	return password.StrengthOf(e.delegate)
+}
+
+func (e *ValidatingPasswordEncoder) EstimateMemory(encodedPassword string) int64 {
+	return password.EstimateMemory(e.delegate, encodedPassword)
+}
+
+// Policy returns the policy, e.g. to check a new password before asking for it again
+func (e *ValidatingPasswordEncoder) Policy() *Policy {
+	return e.policy
+}
@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/xuyang2/password-encoder/keygen"
+	"github.com/xuyang2/password-encoder/password"
+)
+
+func TestValidatingPasswordEncoder(t *testing.T) {
+	encoder := NewValidatingPasswordEncoder(password.NopPasswordEncoder(), DefaultPolicy())
+
+	t.Run("encode", func(t *testing.T) {
+		encodedPassword, err := encoder.Encode("correct horse battery staple")
+		require.NoError(t, err)
+		assert.True(t, encoder.Matches("correct horse battery staple", encodedPassword))
+
+		_, err = encoder.Encode("password")
+		assert.True(t, errors.Is(err, ErrViolation))
+
+		_, err = encoder.EncodeBytes([]byte("password"))
+		assert.True(t, errors.Is(err, ErrViolation))
+
+		_, err = encoder.EncodeContext(context.Background(), "password")
+		assert.True(t, errors.Is(err, ErrViolation))
+	})
+
+	t.Run("input", func(t *testing.T) {
+		_, err := encoder.EncodeInput(context.Background(), Input{Password: "alice.smith-2024!"})
+		assert.NoError(t, err)
+
+		_, err = encoder.EncodeInput(context.Background(), Input{Password: "alice.smith-2024!", Email: "alice.smith@example.com"})
+		var violationErr *ViolationError
+		require.True(t, errors.As(err, &violationErr))
+		assert.Equal(t, []Violation{{Code: CodeContainsUserInfo}}, violationErr.Violations)
+	})
+
+	t.Run("matches violating passwords", func(t *testing.T) {
+		assert.True(t, encoder.Matches("password", "password"))
+		assert.True(t, encoder.MatchesBytes([]byte("password"), "password"))
+		matched, err := encoder.MatchesContext(context.Background(), "password", "password")
+		assert.NoError(t, err)
+		assert.True(t, matched)
+	})
+
+	t.Run("upgrade violating passwords", func(t *testing.T) {
+		legacy := password.NewSha256PasswordEncoder(keygen.NewSecureRandomBytesKeyGenerator(16))
+		encoder := NewValidatingPasswordEncoder(password.NewDelegatingPasswordEncoder("bcrypt", map[string]password.PasswordEncoder{
+			"bcrypt": password.NewBCryptPasswordEncoder(bcrypt.MinCost),
+			"sha256": legacy,
+		}), DefaultPolicy())
+		encodedPassword, err := legacy.Encode("password")
+		require.NoError(t, err)
+
+		matched, newEncodedPassword, err := password.MatchAndUpgrade(encoder, "password", "{sha256}"+encodedPassword)
+		assert.True(t, matched)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(newEncodedPassword, "{bcrypt}"))
+		assert.True(t, encoder.Matches("password", newEncodedPassword))
+	})
+
+	t.Run("delegate", func(t *testing.T) {
+		assert.Equal(t, password.StrengthInsecure, encoder.Strength())
+		assert.Equal(t, password.NopPasswordEncoder().UpgradeEncoding("password"), encoder.UpgradeEncoding("password"))
+		assert.Same(t, encoder.policy, encoder.Policy())
+	})
+}
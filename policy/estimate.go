@@ -0,0 +1,537 @@
+package policy
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common.txt
+var commonTxt string
+
+// FrequencyList is a list of lowercase words ranked by frequency, the most common first.
+type FrequencyList struct {
+	ranks  map[string]int
+	maxLen int // in runes
+}
+
+// NewFrequencyList ranks words in order starting at 1, they are lowercased
+func NewFrequencyList(words []string) *FrequencyList {
+	l := &FrequencyList{ranks: make(map[string]int, len(words))}
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if _, ok := l.ranks[word]; ok || word == "" {
+			continue
+		}
+		l.ranks[word] = i + 1
+		if n := utf8.RuneCountInString(word); n > l.maxLen {
+			l.maxLen = n
+		}
+	}
+	return l
+}
+
+// Rank returns the rank of the lowercase word, 0 if it is not in the list
+func (l *FrequencyList) Rank(word string) int {
+	return l.ranks[word]
+}
+
+func (l *FrequencyList) Contains(word string) bool {
+	return l.ranks[word] > 0
+}
+
+var (
+	commonPasswordsOnce sync.Once
+	commonPasswords     *FrequencyList
+)
+
+// CommonPasswords returns the embedded list of common passwords and words. It has about 430 entries,
+// far fewer than the 30000 passwords and 50000 words of zxcvbn, so estimates of other common passwords are too high.
+// Load a larger list with NewFrequencyList, e.g. from a breach corpus, for NewEstimator and NoDictionaryWords.
+func CommonPasswords() *FrequencyList {
+	commonPasswordsOnce.Do(func() {
+		commonPasswords = NewFrequencyList(strings.Fields(commonTxt))
+	})
+	return commonPasswords
+}
+
+type Pattern string
+
+const (
+	PatternDictionary Pattern = "dictionary"
+	PatternRepeat     Pattern = "repeat"
+	PatternSequence   Pattern = "sequence"
+	PatternSpatial    Pattern = "spatial"
+	PatternYear       Pattern = "year"
+	PatternBruteforce Pattern = "bruteforce"
+)
+
+// Match is the part of a password from rune I to J inclusive, guessed as Pattern
+type Match struct {
+	Pattern      Pattern
+	I, J         int
+	Token        string
+	GuessesLog10 float64
+}
+
+// Estimation is the estimated number of guesses to find a password, as in zxcvbn.
+type Estimation struct {
+	GuessesLog10 float64
+
+	// Score is 0 for fewer than 10^3 guesses, 1 for 10^6, 2 for 10^8, 3 for 10^10, else 4
+	Score int
+
+	// Sequence is the most guessable sequence of matches covering the password, i.e. of the fewest guesses
+	Sequence []Match
+}
+
+const (
+	// longer passwords are estimated by their first runes alone, as zxcvbn truncates them:
+	// the other runes may continue the last match, e.g. of a repeat, and add next to no guesses
+	maxEstimateLength = 100
+
+	bruteforceCardinalityLog10 = 1 // 10 per character
+
+	minGuessesSingleCharLog10 = 1      // 10
+	minGuessesMultiCharLog10  = 1.6990 // 50
+
+	// penalty of each additional match of a sequence, 10^4
+	minGuessesBeforeGrowingSequenceLog10 = 4
+
+	minYearSpace = 20
+
+	// zxcvbn's average degree and starting positions of the QWERTY keyboard
+	keyboardAverageDegree     = 4.595
+	keyboardStartingPositions = 94
+)
+
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+var l33tTable = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '{': 'c', '[': 'c', '<': 'c', '3': 'e', '6': 'g', '9': 'g',
+	'1': 'i', '!': 'i', '|': 'i', '0': 'o', '$': 's', '5': 's', '+': 't', '7': 't', '%': 'x', '2': 'z',
+}
+
+// unl33t undoes l33t substitutions, e.g. "p@ssw0rd" to "password"
+func unl33t(s string) string {
+	return strings.Map(func(r rune) rune {
+		if u, ok := l33tTable[r]; ok {
+			return u
+		}
+		return r
+	}, s)
+}
+
+// Estimator estimates password guessability with frequency lists, as zxcvbn without its date,
+// name and keyboard turn patterns.
+type Estimator struct {
+	lists []*FrequencyList
+}
+
+func NewEstimator(lists ...*FrequencyList) *Estimator {
+	return &Estimator{lists: lists}
+}
+
+// Estimate estimates password with the small CommonPasswords list, see Estimator.Estimate
+func Estimate(password string, userInputs ...string) Estimation {
+	return NewEstimator(CommonPasswords()).Estimate(password, userInputs...)
+}
+
+// Estimate estimates the guesses to find password, userInputs are the most common words,
+// e.g. the username.
+func (e *Estimator) Estimate(password string, userInputs ...string) Estimation {
+	lists := e.lists
+	if len(userInputs) > 0 {
+		lists = append([]*FrequencyList{NewFrequencyList(userInputs)}, lists...)
+	}
+
+	runes := []rune(password)
+	if len(runes) > maxEstimateLength {
+		runes = runes[:maxEstimateLength]
+	}
+
+	est := &estimation{lists: lists, referenceYear: time.Now().Year()}
+	guessesLog10, sequence := est.mostGuessable(runes)
+	return Estimation{
+		GuessesLog10: guessesLog10,
+		Score:        score(guessesLog10),
+		Sequence:     sequence,
+	}
+}
+
+func score(guessesLog10 float64) int {
+	for i, threshold := range []float64{3, 6, 8, 10} {
+		if guessesLog10 < threshold {
+			return i
+		}
+	}
+	return 4
+}
+
+type estimation struct {
+	lists         []*FrequencyList
+	referenceYear int
+}
+
+// candidate is the best sequence of l matches ending with m
+type candidate struct {
+	m  Match
+	pi float64 // log10 of the product of the guesses of the matches
+	g  float64 // log10 of the guesses of the sequence
+}
+
+// mostGuessable returns the sequence of matches of the fewest guesses, as zxcvbn's most_guessable_match_sequence
+func (est *estimation) mostGuessable(runes []rune) (float64, []Match) {
+	n := len(runes)
+	if n == 0 {
+		return 0, nil
+	}
+
+	byEnd := make([][]Match, n)
+	for _, m := range est.matches(runes) {
+		byEnd[m.J] = append(byEnd[m.J], m)
+	}
+
+	// best[k][l] is the best sequence of l matches covering runes[:k+1]
+	best := make([][]*candidate, n)
+	for k := range best {
+		best[k] = make([]*candidate, n+1)
+	}
+	update := func(m Match, l int) {
+		if m.J-m.I+1 < n {
+			floor := minGuessesMultiCharLog10
+			if m.I == m.J {
+				floor = minGuessesSingleCharLog10
+			}
+			m.GuessesLog10 = math.Max(m.GuessesLog10, floor)
+		}
+		pi := m.GuessesLog10
+		if l > 1 {
+			pi += best[m.I-1][l-1].pi
+		}
+		g := logFactorial(l) + pi + float64(l-1)*minGuessesBeforeGrowingSequenceLog10
+		for shorter := 1; shorter <= l; shorter++ {
+			if c := best[m.J][shorter]; c != nil && c.g <= g {
+				return
+			}
+		}
+		best[m.J][l] = &candidate{m: m, pi: pi, g: g}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			if m.I == 0 {
+				update(m, 1)
+				continue
+			}
+			for l, c := range best[m.I-1] {
+				if c != nil {
+					update(m, l+1)
+				}
+			}
+		}
+
+		update(est.bruteforce(runes, 0, k), 1)
+		for i := 1; i <= k; i++ {
+			m := est.bruteforce(runes, i, k)
+			for l, c := range best[i-1] {
+				if c != nil && c.m.Pattern != PatternBruteforce {
+					update(m, l+1)
+				}
+			}
+		}
+	}
+
+	var last *candidate
+	l := 0
+	for cl, c := range best[n-1] {
+		if c != nil && (last == nil || c.g < last.g) {
+			last, l = c, cl
+		}
+	}
+	sequence := make([]Match, l)
+	for k := n - 1; k >= 0; l-- {
+		m := best[k][l].m
+		sequence[l-1] = m
+		k = m.I - 1
+	}
+	return last.g, sequence
+}
+
+func logFactorial(n int) float64 {
+	f := 0.0
+	for i := 2; i <= n; i++ {
+		f += math.Log10(float64(i))
+	}
+	return f
+}
+
+func (est *estimation) bruteforce(runes []rune, i, j int) Match {
+	return Match{
+		Pattern:      PatternBruteforce,
+		I:            i,
+		J:            j,
+		Token:        string(runes[i : j+1]),
+		GuessesLog10: float64(j-i+1) * bruteforceCardinalityLog10,
+	}
+}
+
+func (est *estimation) matches(runes []rune) []Match {
+	var matches []Match
+	matches = append(matches, est.dictionaryMatches(runes)...)
+	matches = append(matches, est.repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, spatialMatches(runes)...)
+	matches = append(matches, est.yearMatches(runes)...)
+	return matches
+}
+
+// rank returns the lowest rank of word in the lists, 0 if none
+func (est *estimation) rank(word string) int {
+	rank := 0
+	for _, l := range est.lists {
+		if r := l.Rank(word); r > 0 && (rank == 0 || r < rank) {
+			rank = r
+		}
+	}
+	return rank
+}
+
+// dictionaryMatches matches words of the lists, also reversed or with l33t substitutions
+func (est *estimation) dictionaryMatches(runes []rune) []Match {
+	maxLen := 0
+	for _, l := range est.lists {
+		if l.maxLen > maxLen {
+			maxLen = l.maxLen
+		}
+	}
+
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		lower = runes // a few runes change length when lowercased
+	}
+
+	var matches []Match
+	for i := range runes {
+		for j := i; j < len(runes) && j-i < maxLen; j++ {
+			token := string(runes[i : j+1])
+			word := string(lower[i : j+1])
+			variationsLog10 := upperVariationsLog10(runes[i : j+1])
+
+			if rank := est.rank(word); rank > 0 {
+				matches = append(matches, dictionaryMatch(i, j, token, rank, variationsLog10))
+			}
+			if reversed := reverse(word); j > i && reversed != word {
+				if rank := est.rank(reversed); rank > 0 {
+					matches = append(matches, dictionaryMatch(i, j, token, rank, variationsLog10+math.Log10(2)))
+				}
+			}
+			if unl33ted := unl33t(word); unl33ted != word {
+				if rank := est.rank(unl33ted); rank > 0 {
+					matches = append(matches, dictionaryMatch(i, j, token, rank, variationsLog10+l33tVariationsLog10(word)))
+				}
+			}
+		}
+	}
+	return matches
+}
+
+func dictionaryMatch(i, j int, token string, rank int, variationsLog10 float64) Match {
+	return Match{
+		Pattern:      PatternDictionary,
+		I:            i,
+		J:            j,
+		Token:        token,
+		GuessesLog10: math.Log10(float64(rank)) + variationsLog10,
+	}
+}
+
+// upperVariationsLog10 is zxcvbn's uppercase_variations:
+// 1 if all lowercase, 2 for the first or last letter or all uppercase, else the number of such capitalizations
+func upperVariationsLog10(token []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 0
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))) {
+		return math.Log10(2)
+	}
+	variations := 0.0
+	for i := 1; i <= upper && i <= lower; i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return math.Log10(variations)
+}
+
+// l33tVariationsLog10 doubles the guesses for each substituted character
+func l33tVariationsLog10(word string) float64 {
+	subs := 0
+	for _, r := range word {
+		if _, ok := l33tTable[r]; ok {
+			subs++
+		}
+	}
+	return float64(subs) * math.Log10(2)
+}
+
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return r
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// repeatMatches matches repetitions of the shortest base at each position, e.g. "aaa" or "abcabc",
+// guessed as the base times the number of repetitions
+func (est *estimation) repeatMatches(runes []rune) []Match {
+	var matches []Match
+	for i := range runes {
+		for period := 1; i+2*period <= len(runes); period++ {
+			j := i + period
+			for j < len(runes) && runes[j] == runes[j-period] {
+				j++
+			}
+			count := (j - i) / period
+			if count < 2 || count*period < 3 {
+				continue
+			}
+
+			baseGuessesLog10, _ := est.mostGuessable(runes[i : i+period])
+			matches = append(matches, Match{
+				Pattern:      PatternRepeat,
+				I:            i,
+				J:            i + count*period - 1,
+				Token:        string(runes[i : i+count*period]),
+				GuessesLog10: baseGuessesLog10 + math.Log10(float64(count)),
+			})
+			break
+		}
+	}
+	return matches
+}
+
+// sequenceMatches matches runs of at least 3 characters with the same difference up to 5, e.g. "abc", "9753"
+func sequenceMatches(runes []rune) []Match {
+	var matches []Match
+	for i := 0; i+2 < len(runes); {
+		delta := runes[i+1] - runes[i]
+		j := i + 1
+		for j+1 < len(runes) && runes[j+1]-runes[j] == delta {
+			j++
+		}
+		if j-i+1 >= 3 && delta != 0 && delta >= -5 && delta <= 5 {
+			matches = append(matches, Match{
+				Pattern:      PatternSequence,
+				I:            i,
+				J:            j,
+				Token:        string(runes[i : j+1]),
+				GuessesLog10: sequenceGuessesLog10(runes[i], j-i+1, delta > 0),
+			})
+		}
+		i = j
+	}
+	return matches
+}
+
+func sequenceGuessesLog10(first rune, length int, ascending bool) float64 {
+	var base float64
+	switch {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4 // obvious starts
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if !ascending {
+		base *= 2
+	}
+	return math.Log10(base * float64(length))
+}
+
+// spatialMatches matches runs of at least 3 adjacent keys of a QWERTY keyboard row, e.g. "qwer" or "lkjh"
+func spatialMatches(runes []rune) []Match {
+	var matches []Match
+	for i := range runes {
+		for _, row := range keyboardRows {
+			pos := strings.IndexRune(row, unicode.ToLower(runes[i]))
+			if pos < 0 {
+				continue
+			}
+			for _, dir := range []int{1, -1} {
+				j := i
+				for j+1 < len(runes) {
+					next := pos + (j+1-i)*dir
+					if next < 0 || next >= len(row) || rune(row[next]) != unicode.ToLower(runes[j+1]) {
+						break
+					}
+					j++
+				}
+				if length := j - i + 1; length >= 3 {
+					matches = append(matches, Match{
+						Pattern:      PatternSpatial,
+						I:            i,
+						J:            j,
+						Token:        string(runes[i : j+1]),
+						GuessesLog10: math.Log10(float64(length-1) * keyboardStartingPositions * keyboardAverageDegree),
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// yearMatches matches years 1900 to 2099, guessed by their distance to the current year
+func (est *estimation) yearMatches(runes []rune) []Match {
+	var matches []Match
+	for i := 0; i+4 <= len(runes); i++ {
+		year := 0
+		for _, r := range runes[i : i+4] {
+			if r < '0' || r > '9' {
+				year = -1
+				break
+			}
+			year = 10*year + int(r-'0')
+		}
+		if year < 1900 || year > 2099 {
+			continue
+		}
+		space := est.referenceYear - year
+		if space < 0 {
+			space = -space
+		}
+		if space < minYearSpace {
+			space = minYearSpace
+		}
+		matches = append(matches, Match{
+			Pattern:      PatternYear,
+			I:            i,
+			J:            i + 3,
+			Token:        string(runes[i : i+4]),
+			GuessesLog10: math.Log10(float64(space)),
+		})
+	}
+	return matches
+}
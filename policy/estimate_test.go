@@ -0,0 +1,79 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		password string
+		score    int
+		patterns []Pattern
+	}{
+		{password: "", score: 0},
+		{password: "password", score: 0, patterns: []Pattern{PatternDictionary}},
+		{password: "drowssap", score: 0, patterns: []Pattern{PatternDictionary}},
+		{password: "P@ssw0rd", score: 0, patterns: []Pattern{PatternDictionary}},
+		{password: "aaaaaaaa", score: 0, patterns: []Pattern{PatternRepeat}},
+		{password: "abcabcabc", score: 0, patterns: []Pattern{PatternRepeat}},
+		{password: "13579", score: 0, patterns: []Pattern{PatternSequence}},
+		{password: "asdfghjkl", score: 1, patterns: []Pattern{PatternSpatial}},
+		{password: "jordan1990", score: 2, patterns: []Pattern{PatternDictionary, PatternYear}},
+		{password: "xk8#Qz!p2L", score: 4, patterns: []Pattern{PatternBruteforce}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			estimation := Estimate(tt.password)
+
+			assert.Equal(t, tt.score, estimation.Score, "%.2f", estimation.GuessesLog10)
+			var patterns []Pattern
+			tokens := ""
+			for _, m := range estimation.Sequence {
+				patterns = append(patterns, m.Pattern)
+				tokens += m.Token
+			}
+			assert.Equal(t, tt.patterns, patterns)
+			assert.Equal(t, tt.password, tokens)
+		})
+	}
+}
+
+func TestEstimate_UpperVariations(t *testing.T) {
+	lower := Estimate("dragon").GuessesLog10
+	assert.Greater(t, Estimate("Dragon").GuessesLog10, lower)
+	assert.Greater(t, Estimate("dRaGoN").GuessesLog10, Estimate("Dragon").GuessesLog10)
+}
+
+func TestEstimate_Long(t *testing.T) {
+	estimation := Estimate(strings.Repeat("a", maxEstimateLength+10))
+	require.Len(t, estimation.Sequence, 1)
+	assert.Equal(t, maxEstimateLength-1, estimation.Sequence[0].J)
+	assert.Less(t, estimation.Score, 2) // the runes beyond maxEstimateLength add no guesses
+	assert.Equal(t, Estimate(strings.Repeat("a", maxEstimateLength)).GuessesLog10, estimation.GuessesLog10)
+
+	assert.Equal(t, 4, Estimate("hZ7#qL!x9@wR2$vN"+strings.Repeat("a", maxEstimateLength)).Score)
+}
+
+func TestEstimator(t *testing.T) {
+	estimator := NewEstimator(NewFrequencyList([]string{"contoso"}))
+
+	assert.Equal(t, 0, estimator.Estimate("contoso").Score)
+	assert.Equal(t, 0, estimator.Estimate("northwind", "Northwind").Score)
+	assert.Greater(t, estimator.Estimate("northwind").Score, 0)
+}
+
+func TestFrequencyList(t *testing.T) {
+	l := NewFrequencyList([]string{"first", "Second", "first", ""})
+
+	assert.Equal(t, 1, l.Rank("first"))
+	assert.Equal(t, 2, l.Rank("second"))
+	assert.Equal(t, 0, l.Rank("third"))
+	assert.True(t, l.Contains("second"))
+
+	assert.Equal(t, 1, CommonPasswords().Rank("123456"))
+	assert.Equal(t, 2, CommonPasswords().Rank("password"))
+}
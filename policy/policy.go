@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrViolation is wrapped by the errors of Policy.Validate and ValidatingPasswordEncoder
+var ErrViolation = errors.New("policy: password violates the policy")
+
+// Input is a new raw password with what it must not contain, e.g. the username.
+type Input struct {
+	Password string
+	Username string
+	Email    string
+
+	// UserInputs are other user or site specific words, e.g. the name or the site name, as in zxcvbn
+	UserInputs []string
+}
+
+// Code identifies a Violation for localization, see Messages
+type Code string
+
+const (
+	CodeTooShort             Code = "too_short"
+	CodeTooLong              Code = "too_long"
+	CodeMissingLower         Code = "missing_lower"
+	CodeMissingUpper         Code = "missing_upper"
+	CodeMissingDigit         Code = "missing_digit"
+	CodeMissingSymbol        Code = "missing_symbol"
+	CodeTooFewClasses        Code = "too_few_classes"
+	CodeContainsUserInfo     Code = "contains_user_info"
+	CodeRepeatedCharacters   Code = "repeated_characters"
+	CodeSequentialCharacters Code = "sequential_characters"
+	CodeDictionaryWord       Code = "dictionary_word"
+	CodeTooGuessable         Code = "too_guessable"
+)
+
+// Violation is a reason a password is rejected, never containing the password.
+// Params are the placeholders of its message, e.g. {"min": 8} for "{min}".
+type Violation struct {
+	Code   Code                   `json:"code"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// Message formats v with the message of its Code in messages, or else in EnglishMessages
+func (v Violation) Message(messages Messages) string {
+	format, ok := messages[v.Code]
+	if !ok {
+		format, ok = EnglishMessages[v.Code]
+	}
+	if !ok {
+		return string(v.Code)
+	}
+
+	names := make([]string, 0, len(v.Params))
+	for name := range v.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	oldnew := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		oldnew = append(oldnew, "{"+name+"}", fmt.Sprint(v.Params[name]))
+	}
+	return strings.NewReplacer(oldnew...).Replace(format)
+}
+
+func (v Violation) String() string {
+	return v.Message(EnglishMessages)
+}
+
+// Messages are the message formats of violations by Code, with "{param}" placeholders
+type Messages map[Code]string
+
+var EnglishMessages = Messages{
+	CodeTooShort:             "must be at least {min} characters",
+	CodeTooLong:              "must be at most {max} characters",
+	CodeMissingLower:         "must contain a lowercase letter",
+	CodeMissingUpper:         "must contain an uppercase letter",
+	CodeMissingDigit:         "must contain a digit",
+	CodeMissingSymbol:        "must contain a symbol",
+	CodeTooFewClasses:        "must contain at least {min} of lowercase letters, uppercase letters, digits and symbols",
+	CodeContainsUserInfo:     "must not contain your username or email",
+	CodeRepeatedCharacters:   "must not repeat a character more than {max} times in a row",
+	CodeSequentialCharacters: "must not contain sequences longer than {max} characters, e.g. abcd or 4321",
+	CodeDictionaryWord:       "must not be a common password or word",
+	CodeTooGuessable:         "is too easy to guess",
+}
+
+var ChineseMessages = Messages{
+	CodeTooShort:             "长度不能少于 {min} 个字符",
+	CodeTooLong:              "长度不能超过 {max} 个字符",
+	CodeMissingLower:         "必须包含小写字母",
+	CodeMissingUpper:         "必须包含大写字母",
+	CodeMissingDigit:         "必须包含数字",
+	CodeMissingSymbol:        "必须包含符号",
+	CodeTooFewClasses:        "必须包含小写字母、大写字母、数字和符号中的至少 {min} 种",
+	CodeContainsUserInfo:     "不能包含用户名或邮箱",
+	CodeRepeatedCharacters:   "同一字符不能连续出现超过 {max} 次",
+	CodeSequentialCharacters: "不能包含超过 {max} 个字符的连续序列，如 abcd 或 4321",
+	CodeDictionaryWord:       "不能是常用密码或单词",
+	CodeTooGuessable:         "太容易被猜到",
+}
+
+// ViolationError is returned for passwords violating a Policy,
+// errors.Is(err, ErrViolation) is true.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.String())
+	}
+	return "policy: password " + strings.Join(messages, "; ")
+}
+
+func (e *ViolationError) Unwrap() error {
+	return ErrViolation
+}
+
+// Rule checks one aspect of a password
+type Rule interface {
+	Check(in Input) []Violation
+}
+
+type RuleFunc func(in Input) []Violation
+
+func (f RuleFunc) Check(in Input) []Violation {
+	return f(in)
+}
+
+// Policy is a composition of Rules, all of them are checked.
+type Policy struct {
+	rules []Rule
+}
+
+var _ Rule = (*Policy)(nil)
+
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// DefaultPolicy follows NIST SP 800-63B: 8 to 64 characters, no user info or common passwords,
+// no composition rules, and a zxcvbn score of at least 2.
+// Its common passwords are the small embedded CommonPasswords list. To reject breached passwords as NIST requires,
+// add NoDictionaryWords of a larger list, or check them with the compromised package.
+func DefaultPolicy() *Policy {
+	return NewPolicy(
+		MinLength(8),
+		MaxLength(64),
+		NoUserInfo(4),
+		NoDictionaryWords(CommonPasswords()),
+		MinScore(2),
+	)
+}
+
+// Check returns the violations of all rules in order, nil if none
+func (p *Policy) Check(in Input) []Violation {
+	var violations []Violation
+	for _, rule := range p.rules {
+		violations = append(violations, rule.Check(in)...)
+	}
+	return violations
+}
+
+// Validate returns a *ViolationError if in.Password violates the policy
+func (p *Policy) Validate(in Input) error {
+	if violations := p.Check(in); len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViolation_Message(t *testing.T) {
+	v := Violation{Code: CodeTooShort, Params: map[string]interface{}{"min": 8}}
+
+	assert.Equal(t, "must be at least 8 characters", v.String())
+	assert.Equal(t, "长度不能少于 8 个字符", v.Message(ChineseMessages))
+	assert.Equal(t, "至少 8 位", v.Message(Messages{CodeTooShort: "至少 {min} 位"}))
+
+	t.Run("fallback", func(t *testing.T) {
+		assert.Equal(t, "must be at least 8 characters", v.Message(Messages{}))
+		assert.Equal(t, "custom", Violation{Code: "custom"}.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"code":"too_short","params":{"min":8}}`, string(b))
+	})
+}
+
+func TestMessages(t *testing.T) {
+	for code := range EnglishMessages {
+		assert.Contains(t, ChineseMessages, code)
+	}
+	assert.Len(t, ChineseMessages, len(EnglishMessages))
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := NewPolicy(MinLength(8), RequireCharacterClasses(Upper|Digit))
+
+	assert.NoError(t, policy.Validate(Input{Password: "Password1"}))
+
+	err := policy.Validate(Input{Password: "pass"})
+	assert.True(t, errors.Is(err, ErrViolation))
+	var violationErr *ViolationError
+	require.True(t, errors.As(err, &violationErr))
+	assert.Equal(t, []Violation{
+		{Code: CodeTooShort, Params: map[string]interface{}{"min": 8}},
+		{Code: CodeMissingUpper},
+		{Code: CodeMissingDigit},
+	}, violationErr.Violations)
+	assert.Equal(t, "policy: password must be at least 8 characters; must contain an uppercase letter; must contain a digit", err.Error())
+	assert.NotContains(t, err.Error(), "pass ")
+
+	t.Run("nested", func(t *testing.T) {
+		nested := NewPolicy(policy, MaxLength(10))
+		assert.Len(t, nested.Check(Input{Password: "password-too-long"}), 3)
+	})
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+
+	tests := []struct {
+		name  string
+		in    Input
+		codes []Code
+	}{
+		{name: "ok", in: Input{Password: "correct horse battery staple"}},
+		{name: "short", in: Input{Password: "x7#Q"}, codes: []Code{CodeTooShort, CodeTooGuessable}},
+		{name: "common", in: Input{Password: "P@ssw0rd1!"}, codes: []Code{CodeDictionaryWord}},
+		{name: "username", in: Input{Password: "alice.smith-2024!", Username: "Alice.Smith"}, codes: []Code{CodeContainsUserInfo}},
+		{name: "guessable", in: Input{Password: "aaaaaaaaaa"}, codes: []Code{CodeTooGuessable}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var codes []Code
+			for _, v := range policy.Check(tt.in) {
+				codes = append(codes, v.Code)
+			}
+			assert.Equal(t, tt.codes, codes)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package policy
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MinLength rejects passwords shorter than min characters, i.e. runes
+func MinLength(min int) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		if utf8.RuneCountInString(in.Password) < min {
+			return []Violation{{Code: CodeTooShort, Params: map[string]interface{}{"min": min}}}
+		}
+		return nil
+	})
+}
+
+// MaxLength rejects passwords longer than max characters, i.e. runes
+func MaxLength(max int) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		if utf8.RuneCountInString(in.Password) > max {
+			return []Violation{{Code: CodeTooLong, Params: map[string]interface{}{"max": max}}}
+		}
+		return nil
+	})
+}
+
+type CharacterClass int
+
+const (
+	Lower  CharacterClass = 1 << iota // unicode.IsLower
+	Upper                             // unicode.IsUpper
+	Digit                             // unicode.IsDigit
+	Symbol                            // unicode.IsPunct, unicode.IsSymbol or a space
+)
+
+var characterClassCodes = []struct {
+	class CharacterClass
+	code  Code
+}{
+	{Lower, CodeMissingLower},
+	{Upper, CodeMissingUpper},
+	{Digit, CodeMissingDigit},
+	{Symbol, CodeMissingSymbol},
+}
+
+// characterClasses returns the classes of the characters of password
+func characterClasses(password string) CharacterClass {
+	var classes CharacterClass
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			classes |= Lower
+		case unicode.IsUpper(r):
+			classes |= Upper
+		case unicode.IsDigit(r):
+			classes |= Digit
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			classes |= Symbol
+		}
+	}
+	return classes
+}
+
+// RequireCharacterClasses rejects passwords without a character of each class, e.g. Lower|Upper|Digit
+func RequireCharacterClasses(classes CharacterClass) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		have := characterClasses(in.Password)
+		var violations []Violation
+		for _, c := range characterClassCodes {
+			if classes&c.class != 0 && have&c.class == 0 {
+				violations = append(violations, Violation{Code: c.code})
+			}
+		}
+		return violations
+	})
+}
+
+// MinCharacterClasses rejects passwords with characters of fewer than min of the 4 classes
+func MinCharacterClasses(min int) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		have := characterClasses(in.Password)
+		count := 0
+		for _, c := range characterClassCodes {
+			if have&c.class != 0 {
+				count++
+			}
+		}
+		if count < min {
+			return []Violation{{Code: CodeTooFewClasses, Params: map[string]interface{}{"min": min}}}
+		}
+		return nil
+	})
+}
+
+// NoUserInfo rejects passwords containing the username, the email or its local part, or a UserInput,
+// ignoring case and those shorter than minLen characters.
+func NoUserInfo(minLen int) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		lower := strings.ToLower(in.Password)
+		for _, info := range userInfo(in) {
+			if utf8.RuneCountInString(info) >= minLen && strings.Contains(lower, info) {
+				return []Violation{{Code: CodeContainsUserInfo}}
+			}
+		}
+		return nil
+	})
+}
+
+// userInfo returns the lowercase user specific words of in
+func userInfo(in Input) []string {
+	infos := make([]string, 0, 3+len(in.UserInputs))
+	if in.Username != "" {
+		infos = append(infos, strings.ToLower(in.Username))
+	}
+	if in.Email != "" {
+		email := strings.ToLower(in.Email)
+		infos = append(infos, email)
+		if i := strings.LastIndex(email, "@"); i > 0 {
+			infos = append(infos, email[:i])
+		}
+	}
+	for _, input := range in.UserInputs {
+		if input != "" {
+			infos = append(infos, strings.ToLower(input))
+		}
+	}
+	return infos
+}
+
+// MaxRepeated rejects passwords repeating a character more than max times in a row, e.g. "aaaa" for 3
+func MaxRepeated(max int) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		var prev rune
+		run := 0
+		for _, r := range in.Password {
+			if run > 0 && r == prev {
+				run++
+			} else {
+				prev, run = r, 1
+			}
+			if run > max {
+				return []Violation{{Code: CodeRepeatedCharacters, Params: map[string]interface{}{"max": max}}}
+			}
+		}
+		return nil
+	})
+}
+
+// MaxSequential rejects passwords with more than max consecutive ascending or descending characters,
+// e.g. "abcd" or "4321" for 3
+func MaxSequential(max int) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		if sequenceLength(in.Password) > max {
+			return []Violation{{Code: CodeSequentialCharacters, Params: map[string]interface{}{"max": max}}}
+		}
+		return nil
+	})
+}
+
+// sequenceLength returns the length of the longest run of characters with a difference of 1, or of -1
+func sequenceLength(password string) int {
+	longest, run := 0, 0
+	var prev, delta rune
+	for _, r := range password {
+		d := r - prev
+		if run > 0 && (d == 1 || d == -1) {
+			if run == 1 || d == delta {
+				run++
+			} else {
+				run = 2 // reversed, e.g. "cdc"
+			}
+			delta = d
+		} else {
+			run = 1
+		}
+		prev = r
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// NoDictionaryWords rejects passwords that are a word of list ignoring case, l33t substitutions,
+// and leading and trailing digits and symbols, e.g. "P@ssw0rd1!" for "password".
+func NoDictionaryWords(list *FrequencyList) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		lower := strings.ToLower(in.Password)
+		trimmed := strings.TrimFunc(lower, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		for _, candidate := range []string{lower, trimmed, unl33t(lower), unl33t(trimmed)} {
+			if list.Contains(candidate) {
+				return []Violation{{Code: CodeDictionaryWord}}
+			}
+		}
+		return nil
+	})
+}
+
+// MinScore rejects passwords with an estimated Score less than min, with the user info of the Input
+// as dictionary words. See Estimate.
+func MinScore(min int) Rule {
+	return RuleFunc(func(in Input) []Violation {
+		if estimate := Estimate(in.Password, userInfo(in)...); estimate.Score < min {
+			return []Violation{{Code: CodeTooGuessable, Params: map[string]interface{}{"min": min, "score": estimate.Score}}}
+		}
+		return nil
+	})
+}
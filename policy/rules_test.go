@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func codes(rule Rule, in Input) []Code {
+	var codes []Code
+	for _, v := range rule.Check(in) {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestLength(t *testing.T) {
+	assert.Empty(t, codes(MinLength(4), Input{Password: "密码密码"}), "runes")
+	assert.Equal(t, []Code{CodeTooShort}, codes(MinLength(4), Input{Password: "密码"}))
+
+	assert.Empty(t, codes(MaxLength(4), Input{Password: "密码密码"}))
+	assert.Equal(t, []Code{CodeTooLong}, codes(MaxLength(4), Input{Password: "12345"}))
+}
+
+func TestCharacterClasses(t *testing.T) {
+	assert.Empty(t, codes(RequireCharacterClasses(Lower|Upper|Digit|Symbol), Input{Password: "aB3 "}))
+	assert.Equal(t, []Code{CodeMissingLower, CodeMissingSymbol}, codes(RequireCharacterClasses(Lower|Upper|Digit|Symbol), Input{Password: "AB3"}))
+	assert.Empty(t, codes(RequireCharacterClasses(Digit), Input{Password: "٣"}), "unicode digits")
+
+	assert.Empty(t, codes(MinCharacterClasses(3), Input{Password: "ab3$"}))
+	assert.Equal(t, []Code{CodeTooFewClasses}, codes(MinCharacterClasses(3), Input{Password: "ab3"}))
+}
+
+func TestNoUserInfo(t *testing.T) {
+	rule := NoUserInfo(4)
+
+	tests := []struct {
+		name string
+		in   Input
+		want []Code
+	}{
+		{name: "none", in: Input{Password: "s3cret-passphrase"}},
+		{name: "username", in: Input{Password: "my-ALICE-pw", Username: "alice"}, want: []Code{CodeContainsUserInfo}},
+		{name: "email local part", in: Input{Password: "bob.jones99", Email: "Bob.Jones@example.com"}, want: []Code{CodeContainsUserInfo}},
+		{name: "email", in: Input{Password: "x-bob@example.com", Email: "bob@example.com"}, want: []Code{CodeContainsUserInfo}},
+		{name: "user input", in: Input{Password: "acme-corp-1", UserInputs: []string{"Acme"}}, want: []Code{CodeContainsUserInfo}},
+		{name: "short", in: Input{Password: "bob-is-here", Username: "bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, codes(rule, tt.in))
+		})
+	}
+}
+
+func TestMaxRepeated(t *testing.T) {
+	assert.Empty(t, codes(MaxRepeated(3), Input{Password: "aaabbbccc"}))
+	assert.Equal(t, []Code{CodeRepeatedCharacters}, codes(MaxRepeated(3), Input{Password: "xaaaa"}))
+}
+
+func TestMaxSequential(t *testing.T) {
+	assert.Empty(t, codes(MaxSequential(3), Input{Password: "abc-321-cdc"}))
+	assert.Equal(t, []Code{CodeSequentialCharacters}, codes(MaxSequential(3), Input{Password: "x1234"}))
+	assert.Equal(t, []Code{CodeSequentialCharacters}, codes(MaxSequential(3), Input{Password: "zyxw"}))
+
+	assert.Equal(t, 0, sequenceLength(""))
+	assert.Equal(t, 3, sequenceLength("cdcb"))
+}
+
+func TestNoDictionaryWords(t *testing.T) {
+	rule := NoDictionaryWords(CommonPasswords())
+
+	for _, rawPassword := range []string{"password", "PASSWORD", "P@ssw0rd", "Dragon123!", "1qaz2wsx"} {
+		assert.Equal(t, []Code{CodeDictionaryWord}, codes(rule, Input{Password: rawPassword}), rawPassword)
+	}
+	assert.Empty(t, codes(rule, Input{Password: "dragon-slayer-42"}))
+
+	custom := NoDictionaryWords(NewFrequencyList([]string{"Contoso"}))
+	assert.Equal(t, []Code{CodeDictionaryWord}, codes(custom, Input{Password: "contoso2024"}))
+}
+
+func TestMinScore(t *testing.T) {
+	assert.Empty(t, codes(MinScore(3), Input{Password: "7Kq#vT9m!xWp"}))
+
+	violations := MinScore(3).Check(Input{Password: "qwerty"})
+	assert.Equal(t, []Violation{{Code: CodeTooGuessable, Params: map[string]interface{}{"min": 3, "score": 0}}}, violations)
+
+	// the username is the most common word
+	assert.Empty(t, codes(MinScore(2), Input{Password: "zorblax"}))
+	assert.Equal(t, []Code{CodeTooGuessable}, codes(MinScore(2), Input{Password: "zorblax", Username: "Zorblax"}))
+}